@@ -0,0 +1,50 @@
+// Package authz decides what the signed in users may do. Every operation requires a
+// minimum role, enforced by the Authorizer middleware, and the services check that
+// customers only act on their own account and orders with RequireSelfOr.
+package authz
+
+import (
+	"context"
+	"fmt"
+	"swagger_petstore/entity"
+)
+
+type principalKey struct{}
+
+// Principal is the signed in user of a request with its current role.
+type Principal struct {
+	Username string
+	Role     entity.Role
+}
+
+func NewContext(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// FromContext returns the principal found by the Authorizer, it reports false for
+// anonymous requests and outside of a request.
+func FromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
+
+// RequireRole returns entity.ErrForbidden unless the caller has at least role.
+func RequireRole(ctx context.Context, role entity.Role) error {
+	principal, ok := FromContext(ctx)
+	if !ok {
+		return fmt.Errorf("%w: sign in required", entity.ErrForbidden)
+	}
+	if !principal.Role.AtLeast(role) {
+		return fmt.Errorf("%w: requires the %s role", entity.ErrForbidden, role)
+	}
+	return nil
+}
+
+// RequireSelfOr returns entity.ErrForbidden unless the caller is username or has at
+// least role.
+func RequireSelfOr(ctx context.Context, username string, role entity.Role) error {
+	if principal, ok := FromContext(ctx); ok && username != "" && principal.Username == username {
+		return nil
+	}
+	return RequireRole(ctx, role)
+}
@@ -0,0 +1,115 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"swagger_petstore/entity"
+	"swagger_petstore/metrics"
+	"swagger_petstore/middleware"
+	"swagger_petstore/responder"
+
+	"go.uber.org/zap"
+)
+
+// Lookup returns the current role of an active user, or entity.ErrNotFound.
+type Lookup func(ctx context.Context, username string) (entity.Role, error)
+
+// DefaultPolicy is the minimum role of the operations, keyed like the rate limits by
+// operationId or by "METHOD /route" outside the OpenAPI spec. Operations not listed are
+// open to every signed in user.
+func DefaultPolicy() map[string]entity.Role {
+	return map[string]entity.Role{
+		"AddPet":                        entity.RoleStaff,
+		"UpdatePet":                     entity.RoleStaff,
+		"UpdatePetWithForm":             entity.RoleStaff,
+		"UploadFile":                    entity.RoleStaff,
+		"DeletePet":                     entity.RoleStaff,
+		"PATCH /pet/{petId}":            entity.RoleStaff,
+		"POST /pet/import":              entity.RoleStaff,
+		"GET /pet/{petId}/history":      entity.RoleStaff,
+		"GET /jobs/{jobId}":             entity.RoleStaff,
+		"GET /store/inventory/history":  entity.RoleStaff,
+		"GET /events":                   entity.RoleStaff,
+		"GET /export/pets":              entity.RoleStaff,
+		"GET /export/orders":            entity.RoleStaff,
+		"GET /export/users":             entity.RoleStaff,
+		"POST /category":                entity.RoleStaff,
+		"PUT /category/{categoryId}":    entity.RoleStaff,
+		"DELETE /category/{categoryId}": entity.RoleStaff,
+		"DELETE /tag/unused":            entity.RoleStaff,
+		"PUT /tag/{tagId}":              entity.RoleStaff,
+		"DELETE /tag/{tagId}":           entity.RoleStaff,
+		"POST /tag/{tagId}/merge":       entity.RoleStaff,
+
+		"POST /pet/search/reindex":                                     entity.RoleAdmin,
+		"POST /pet/{petId}/restore":                                    entity.RoleAdmin,
+		"GET /audit":                                                   entity.RoleAdmin,
+		"POST /user/{username}/restore":                                entity.RoleAdmin,
+		"POST /user/{username}/unlock":                                 entity.RoleAdmin,
+		"PUT /user/{username}/role":                                    entity.RoleAdmin,
+		"POST /webhooks":                                               entity.RoleAdmin,
+		"GET /webhooks":                                                entity.RoleAdmin,
+		"GET /webhooks/{webhookId}":                                    entity.RoleAdmin,
+		"PUT /webhooks/{webhookId}":                                    entity.RoleAdmin,
+		"DELETE /webhooks/{webhookId}":                                 entity.RoleAdmin,
+		"GET /webhooks/{webhookId}/deliveries":                         entity.RoleAdmin,
+		"POST /webhooks/{webhookId}/deliveries/{deliveryId}/redeliver": entity.RoleAdmin,
+	}
+}
+
+type Authorizer struct {
+	policy     map[string]entity.Role
+	operations map[string]string
+	lookup     Lookup
+	responder  responder.Responder
+	logger     *zap.Logger
+}
+
+// New enforces policy, operations maps the routes to their operationId. The role is looked
+// up on every request, so a role change or a deleted user applies to the tokens already
+// issued.
+func New(policy map[string]entity.Role, operations map[string]string, lookup Lookup,
+	responder responder.Responder, logger *zap.Logger) *Authorizer {
+	return &Authorizer{
+		policy:     policy,
+		operations: operations,
+		lookup:     lookup,
+		responder:  responder,
+		logger:     logger,
+	}
+}
+
+// Middleware must follow middleware.TokenMiddleware. It stores the Principal of the token
+// and answers 403 when its role is below the one of the operation, or 401 when the user
+// is gone.
+func (a *Authorizer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		username := middleware.UserID(ctx)
+		if username == "" {
+			a.responder.ErrorUnauthorized(w, fmt.Errorf("token without user"))
+			return
+		}
+		role, err := a.lookup(ctx, username)
+		if errors.Is(err, entity.ErrNotFound) {
+			a.responder.ErrorUnauthorized(w, fmt.Errorf("user %s no longer exists", username))
+			return
+		}
+		if err != nil {
+			a.logger.Error("authz: failed to look up role", zap.String("user", username), zap.Error(err))
+			a.responder.ErrorInternal(w, fmt.Errorf("failed to look up role"))
+			return
+		}
+
+		ctx = NewContext(ctx, Principal{Username: username, Role: role})
+		if required, ok := a.policy[metrics.RequestOperation(a.operations, r)]; ok {
+			if err := RequireRole(ctx, required); err != nil {
+				a.responder.ErrorForbidden(w, err)
+				return
+			}
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,17 @@
+// Package backoff spaces out the retries of failed work.
+package backoff
+
+import "time"
+
+// Exponential returns the delay before the given attempt: base before the first one,
+// doubling with every attempt after it up to max, and none before attempt 1.
+func Exponential(base, max time.Duration, attempt int) time.Duration {
+	if attempt < 1 {
+		return 0
+	}
+	delay := base
+	for i := 1; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	return min(delay, max)
+}
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"fmt"
 	"os"
 	_ "swagger_petstore/cmd/docs"
 	"swagger_petstore/config"
@@ -22,17 +25,25 @@ import (
 // @in							header
 // @name						Authorization
 func main() {
-	logger := logging.GetLogger()
-
-	conf, err := config.LoadDBConfig()
+	conf, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(run.NoError)
+	}
 	if err != nil {
-		logger.Fatal("Failed to load DB config: ", zap.Error(err))
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(run.GeneralError)
 	}
 
-	db := postgres.NewPostgresDB(conf, logger)
-	defer db.Close()
+	logger := logging.GetLogger(conf.Log.Level, conf.Log.Format)
+
+	// The application closes the database once it has stopped.
+	db, err := postgres.NewPostgresDB(&conf.DB)
+	if err != nil {
+		logger.Error("failed to open database", zap.Error(err))
+		os.Exit(run.GeneralError)
+	}
 
-	app := run.NewApp(db, logger)
+	app := run.NewApp(db, logger, conf)
 
 	exitCode := app.
 		Bootstrap().
@@ -1,37 +1,163 @@
+// Package config loads the settings of the service. Every setting has a default and can be
+// overridden, in order of precedence, by a YAML file, an environment variable and a
+// command line flag.
 package config
 
 import (
 	"fmt"
-	"log"
-	"os"
-
-	"github.com/joho/godotenv"
+	"time"
 )
 
+type Config struct {
+	Server    ServerConfig    `yaml:"server"`
+	DB        DBConfig        `yaml:"db"`
+	Auth      AuthConfig      `yaml:"auth"`
+	Swagger   SwaggerConfig   `yaml:"swagger"`
+	Log       LogConfig       `yaml:"log"`
+	Tracing   TracingConfig   `yaml:"tracing"`
+	Events    EventsConfig    `yaml:"events"`
+	RateLimit RateLimitConfig `yaml:"rateLimit"`
+}
+
+type ServerConfig struct {
+	Addr         string        `yaml:"addr"`
+	ReadTimeout  time.Duration `yaml:"readTimeout"`
+	WriteTimeout time.Duration `yaml:"writeTimeout"`
+	// ShutdownTimeout is how long the requests in flight may run after a shutdown signal.
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout"`
+	// ShutdownDelay is how long readiness fails before the server stops taking requests,
+	// so that load balancers move the traffic away first.
+	ShutdownDelay time.Duration `yaml:"shutdownDelay"`
+	TLS           TLSConfig     `yaml:"tls"`
+	// TrustedProxies are the IPs or CIDRs whose X-Forwarded-For header names the client.
+	TrustedProxies []string `yaml:"trustedProxies"`
+}
+
+// TLSConfig enables HTTPS when CertFile and KeyFile are set. The files are reloaded when
+// they change, so rotated certificates are picked up without a restart.
+type TLSConfig struct {
+	CertFile string `yaml:"certFile"`
+	KeyFile  string `yaml:"keyFile"`
+	// MinVersion is 1.2 or 1.3.
+	MinVersion string `yaml:"minVersion"`
+	// CipherSuites restricts the TLS 1.2 cipher suites by their Go names, TLS 1.3 suites
+	// are not configurable.
+	CipherSuites []string `yaml:"cipherSuites"`
+	// ClientCAFile enables mutual TLS: admin routes then require a client certificate
+	// signed by one of these CAs.
+	ClientCAFile string `yaml:"clientCAFile"`
+	// RedirectAddr starts a plain HTTP listener redirecting to HTTPS when set.
+	RedirectAddr string `yaml:"redirectAddr"`
+}
+
+// Enabled reports whether the server serves HTTPS.
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != ""
+}
+
 type DBConfig struct {
-	Host     string
-	Port     string
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
-}
-
-func LoadDBConfig() (*DBConfig, error) {
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatal("Error loading .env file")
-		return nil, err
-	}
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	DBName   string `yaml:"name"`
+	SSLMode  string `yaml:"sslmode"`
+}
+
+type AuthConfig struct {
+	// JWTSecret signs the access tokens, changing it signs everybody out.
+	JWTSecret string        `yaml:"jwtSecret"`
+	TokenTTL  time.Duration `yaml:"tokenTTL"`
+	// LockoutThreshold failed logins of a username within LockoutDuration lock it for
+	// LockoutDuration, 0 disables the lockout. Failures before it slow the next attempt down.
+	LockoutThreshold int `yaml:"lockoutThreshold"`
+	// LockoutIPThreshold does the same for a client IP over all usernames.
+	LockoutIPThreshold int           `yaml:"lockoutIpThreshold"`
+	LockoutDuration    time.Duration `yaml:"lockoutDuration"`
+	// Admins are granted the admin role at startup, they must be registered beforehand:
+	// the application refuses to start otherwise, so nobody else can take a free name.
+	// Other roles are assigned by admins.
+	Admins []string `yaml:"admins"`
+}
+
+type SwaggerConfig struct {
+	// URL is where the Swagger UI loads the API description from.
+	URL string `yaml:"url"`
+}
+
+type LogConfig struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+type TracingConfig struct {
+	// Exporter is one of none, console or otlp. The OTLP exporter reads the standard
+	// OTEL_EXPORTER_OTLP_* variables.
+	Exporter string `yaml:"exporter"`
+}
+
+type EventsConfig struct {
+	// WebhookURL receives every domain event when set.
+	WebhookURL string `yaml:"webhookUrl"`
+}
 
-	return &DBConfig{
-		Host:     os.Getenv("DB_HOST"),
-		Port:     os.Getenv("DB_PORT"),
-		User:     os.Getenv("DB_USER"),
-		Password: os.Getenv("DB_PASSWORD"),
-		DBName:   os.Getenv("DB_NAME"),
-		SSLMode:  os.Getenv("DB_SSLMODE"),
-	}, nil
+// RateLimitConfig limits the requests of every client, told apart by user or IP. Limits
+// are written as "<requests>/<period>", such as 10/1m, or off.
+type RateLimitConfig struct {
+	// Default is shared by the operations without a limit in Routes.
+	Default string `yaml:"default"`
+	// Routes limits operations by operationId, or by "METHOD /route" for routes outside
+	// the OpenAPI spec, each with buckets of its own.
+	Routes map[string]string `yaml:"routes"`
+	// Store keeps the buckets in memory, or in postgres to share them between instances.
+	Store string `yaml:"store"`
+}
+
+// Default returns the configuration used for the settings that are not given.
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Addr:            ":8080",
+			ReadTimeout:     10 * time.Second,
+			WriteTimeout:    10 * time.Second,
+			ShutdownTimeout: 15 * time.Second,
+			ShutdownDelay:   5 * time.Second,
+			TLS: TLSConfig{
+				MinVersion: "1.2",
+			},
+		},
+		DB: DBConfig{
+			Port:    "5432",
+			SSLMode: "disable",
+		},
+		Auth: AuthConfig{
+			TokenTTL:           time.Hour,
+			LockoutThreshold:   5,
+			LockoutIPThreshold: 20,
+			LockoutDuration:    15 * time.Minute,
+		},
+		Swagger: SwaggerConfig{
+			URL: "/swagger/doc.json",
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Tracing: TracingConfig{
+			Exporter: "none",
+		},
+		RateLimit: RateLimitConfig{
+			Default: "600/1m",
+			Routes: map[string]string{
+				"LoginUser":      "10/1m",
+				"FindPetsByTags": "60/1m",
+				"GET /healthz":   "off",
+				"GET /readyz":    "off",
+				"GET /metrics":   "off",
+			},
+			Store: "memory",
+		},
+	}
 }
 
 func (c *DBConfig) GetDBURL() string {
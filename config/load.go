@@ -0,0 +1,276 @@
+package config
+
+import (
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"swagger_petstore/middleware"
+	"swagger_petstore/ratelimit"
+	"time"
+
+	"github.com/joho/godotenv"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv names the YAML file to read when the -config flag is not given.
+const FileEnv = "CONFIG_FILE"
+
+// minSecretLength keeps guessable JWT secrets out of production.
+const minSecretLength = 16
+
+// setting binds a configuration field to its environment variable and flag.
+type setting struct {
+	key   string
+	env   string
+	flag  string
+	usage string
+	field func(c *Config) interface{}
+}
+
+var settings = []setting{
+	{"server.addr", "HTTP_ADDR", "addr", "listen address", func(c *Config) interface{} { return &c.Server.Addr }},
+	{"server.readTimeout", "HTTP_READ_TIMEOUT", "read-timeout", "maximum duration for reading a request", func(c *Config) interface{} { return &c.Server.ReadTimeout }},
+	{"server.writeTimeout", "HTTP_WRITE_TIMEOUT", "write-timeout", "maximum duration for writing a response", func(c *Config) interface{} { return &c.Server.WriteTimeout }},
+	{"server.shutdownTimeout", "SHUTDOWN_TIMEOUT", "shutdown-timeout", "time given to the requests in flight on shutdown", func(c *Config) interface{} { return &c.Server.ShutdownTimeout }},
+	{"server.shutdownDelay", "SHUTDOWN_DELAY", "shutdown-delay", "time readiness fails before the server stops on shutdown", func(c *Config) interface{} { return &c.Server.ShutdownDelay }},
+	{"server.tls.certFile", "TLS_CERT_FILE", "tls-cert", "TLS certificate file, enables HTTPS", func(c *Config) interface{} { return &c.Server.TLS.CertFile }},
+	{"server.tls.keyFile", "TLS_KEY_FILE", "tls-key", "TLS private key file", func(c *Config) interface{} { return &c.Server.TLS.KeyFile }},
+	{"server.tls.minVersion", "TLS_MIN_VERSION", "tls-min-version", "minimum TLS version: 1.2 or 1.3", func(c *Config) interface{} { return &c.Server.TLS.MinVersion }},
+	{"server.tls.cipherSuites", "TLS_CIPHER_SUITES", "tls-cipher-suites", "comma separated TLS 1.2 cipher suites", func(c *Config) interface{} { return &c.Server.TLS.CipherSuites }},
+	{"server.tls.clientCAFile", "TLS_CLIENT_CA_FILE", "tls-client-ca", "CA file verifying the client certificates of admin routes", func(c *Config) interface{} { return &c.Server.TLS.ClientCAFile }},
+	{"server.tls.redirectAddr", "HTTP_REDIRECT_ADDR", "http-redirect-addr", "plain HTTP listen address redirecting to HTTPS", func(c *Config) interface{} { return &c.Server.TLS.RedirectAddr }},
+	{"server.trustedProxies", "TRUSTED_PROXIES", "trusted-proxies", "comma separated proxies whose X-Forwarded-For is trusted", func(c *Config) interface{} { return &c.Server.TrustedProxies }},
+	{"db.host", "DB_HOST", "db-host", "database host", func(c *Config) interface{} { return &c.DB.Host }},
+	{"db.port", "DB_PORT", "db-port", "database port", func(c *Config) interface{} { return &c.DB.Port }},
+	{"db.user", "DB_USER", "db-user", "database user", func(c *Config) interface{} { return &c.DB.User }},
+	{"db.password", "DB_PASSWORD", "db-password", "database password", func(c *Config) interface{} { return &c.DB.Password }},
+	{"db.name", "DB_NAME", "db-name", "database name", func(c *Config) interface{} { return &c.DB.DBName }},
+	{"db.sslmode", "DB_SSLMODE", "db-sslmode", "database SSL mode", func(c *Config) interface{} { return &c.DB.SSLMode }},
+	{"auth.jwtSecret", "JWT_SECRET", "jwt-secret", "secret signing the access tokens", func(c *Config) interface{} { return &c.Auth.JWTSecret }},
+	{"auth.tokenTTL", "TOKEN_TTL", "token-ttl", "lifetime of the access tokens", func(c *Config) interface{} { return &c.Auth.TokenTTL }},
+	{"auth.lockoutThreshold", "LOGIN_LOCKOUT_THRESHOLD", "login-lockout-threshold", "failed logins locking a username, 0 disables", func(c *Config) interface{} { return &c.Auth.LockoutThreshold }},
+	{"auth.lockoutIpThreshold", "LOGIN_LOCKOUT_IP_THRESHOLD", "login-lockout-ip-threshold", "failed logins locking a client IP, 0 disables", func(c *Config) interface{} { return &c.Auth.LockoutIPThreshold }},
+	{"auth.lockoutDuration", "LOGIN_LOCKOUT_DURATION", "login-lockout-duration", "how long failed logins are counted and a lockout lasts", func(c *Config) interface{} { return &c.Auth.LockoutDuration }},
+	{"auth.admins", "ADMIN_USERS", "admin-users", "comma separated users granted the admin role at startup", func(c *Config) interface{} { return &c.Auth.Admins }},
+	{"swagger.url", "SWAGGER_URL", "swagger-url", "URL of the API description loaded by the Swagger UI", func(c *Config) interface{} { return &c.Swagger.URL }},
+	{"log.level", "LOG_LEVEL", "log-level", "log level: debug, info, warn or error", func(c *Config) interface{} { return &c.Log.Level }},
+	{"log.format", "LOG_FORMAT", "log-format", "log format: json or console", func(c *Config) interface{} { return &c.Log.Format }},
+	{"tracing.exporter", "OTEL_TRACES_EXPORTER", "traces-exporter", "traces exporter: none, console or otlp", func(c *Config) interface{} { return &c.Tracing.Exporter }},
+	{"events.webhookUrl", "EVENTS_WEBHOOK_URL", "events-webhook-url", "URL receiving every domain event", func(c *Config) interface{} { return &c.Events.WebhookURL }},
+	{"rateLimit.default", "RATE_LIMIT_DEFAULT", "rate-limit", "requests per client across the operations without a limit, such as 600/1m, or off", func(c *Config) interface{} { return &c.RateLimit.Default }},
+	{"rateLimit.routes", "RATE_LIMIT_ROUTES", "rate-limit-routes", "comma separated limits by operation, such as LoginUser=10/1m", func(c *Config) interface{} { return &c.RateLimit.Routes }},
+	{"rateLimit.store", "RATE_LIMIT_STORE", "rate-limit-store", "rate limit buckets store: memory or postgres", func(c *Config) interface{} { return &c.RateLimit.Store }},
+}
+
+// Load builds the configuration from the defaults, the YAML file given with -config or
+// CONFIG_FILE, the environment (including an optional .env file) and the flags in args,
+// each overriding the previous ones. All invalid settings are reported in one error.
+func Load(args []string) (*Config, error) {
+	// Variables already set in the environment win over the .env file.
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to load .env: %w", err)
+	}
+
+	flags := flag.NewFlagSet("petstore", flag.ContinueOnError)
+	file := flags.String("config", os.Getenv(FileEnv), "path to a YAML configuration file, "+FileEnv)
+	given := map[string]string{}
+	for _, s := range settings {
+		flags.Func(s.flag, fmt.Sprintf("%s, %s", s.usage, s.env), func(value string) error {
+			given[s.flag] = value
+			return nil
+		})
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %v", flags.Args())
+	}
+
+	conf := Default()
+	if *file != "" {
+		if err := readFile(&conf, *file); err != nil {
+			return nil, err
+		}
+	}
+
+	var errs []error
+	for _, s := range settings {
+		if value := os.Getenv(s.env); value != "" {
+			if err := set(s.field(&conf), value); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %s: %w", s.key, s.env, err))
+			}
+		}
+	}
+	for _, s := range settings {
+		if value, ok := given[s.flag]; ok {
+			if err := set(s.field(&conf), value); err != nil {
+				errs = append(errs, fmt.Errorf("%s: -%s: %w", s.key, s.flag, err))
+			}
+		}
+	}
+	errs = append(errs, conf.validate()...)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+	return &conf, nil
+}
+
+// readFile applies the settings of a YAML file, unknown keys are rejected to catch typos.
+func readFile(conf *Config, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	defer f.Close()
+
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(conf); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func set(field interface{}, value string) error {
+	switch field := field.(type) {
+	case *string:
+		*field = value
+	case *[]string:
+		*field = nil
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*field = append(*field, item)
+			}
+		}
+	case *int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		*field = n
+	case *map[string]string:
+		// Entries are added to the ones already set, so a default can be overridden alone.
+		if *field == nil {
+			*field = map[string]string{}
+		}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			k, v, ok := strings.Cut(item, "=")
+			if !ok {
+				return fmt.Errorf("invalid entry %q: must be key=value", item)
+			}
+			(*field)[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	case *time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", value)
+		}
+		*field = d
+	default:
+		return fmt.Errorf("unsupported setting type %T", field)
+	}
+	return nil
+}
+
+func (c *Config) validate() []error {
+	var errs []error
+	check := func(ok bool, key, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	_, port, err := net.SplitHostPort(c.Server.Addr)
+	check(err == nil && validPort(port), "server.addr", "must be host:port or :port, got %q", c.Server.Addr)
+	check(c.Server.ReadTimeout > 0, "server.readTimeout", "must be positive")
+	check(c.Server.WriteTimeout > 0, "server.writeTimeout", "must be positive")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdownTimeout", "must be positive")
+	check(c.Server.ShutdownDelay >= 0, "server.shutdownDelay", "must not be negative")
+
+	if tlsConf := c.Server.TLS; tlsConf.Enabled() {
+		check(tlsConf.CertFile != "" && tlsConf.KeyFile != "", "server.tls", "certFile and keyFile must be set together")
+		check(slices.Contains([]string{"1.2", "1.3"}, tlsConf.MinVersion), "server.tls.minVersion", "must be 1.2 or 1.3, got %q", tlsConf.MinVersion)
+		for _, name := range tlsConf.CipherSuites {
+			_, err := CipherSuite(name)
+			check(err == nil, "server.tls.cipherSuites", "%v", err)
+		}
+		if tlsConf.RedirectAddr != "" {
+			_, port, err := net.SplitHostPort(tlsConf.RedirectAddr)
+			check(err == nil && validPort(port), "server.tls.redirectAddr", "must be host:port or :port, got %q", tlsConf.RedirectAddr)
+		}
+	} else {
+		check(tlsConf.ClientCAFile == "", "server.tls.clientCAFile", "requires certFile and keyFile")
+		check(tlsConf.RedirectAddr == "", "server.tls.redirectAddr", "requires certFile and keyFile")
+	}
+
+	_, err = middleware.ParseProxies(c.Server.TrustedProxies)
+	check(err == nil, "server.trustedProxies", "%v", err)
+
+	check(c.DB.Host != "", "db.host", "required")
+	check(validPort(c.DB.Port), "db.port", "must be a port number, got %q", c.DB.Port)
+	check(c.DB.User != "", "db.user", "required")
+	check(c.DB.DBName != "", "db.name", "required")
+	sslModes := []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+	check(slices.Contains(sslModes, c.DB.SSLMode), "db.sslmode", "must be one of %v, got %q", sslModes, c.DB.SSLMode)
+
+	check(len(c.Auth.JWTSecret) >= minSecretLength, "auth.jwtSecret", "must be at least %d characters", minSecretLength)
+	check(c.Auth.TokenTTL > 0, "auth.tokenTTL", "must be positive")
+	check(c.Auth.LockoutThreshold >= 0, "auth.lockoutThreshold", "must not be negative")
+	check(c.Auth.LockoutIPThreshold >= 0, "auth.lockoutIpThreshold", "must not be negative")
+	check(c.Auth.LockoutDuration > 0, "auth.lockoutDuration", "must be positive")
+
+	check(c.Swagger.URL != "", "swagger.url", "required")
+
+	_, err = zapcore.ParseLevel(c.Log.Level)
+	check(err == nil, "log.level", "must be one of debug, info, warn, error, got %q", c.Log.Level)
+	check(slices.Contains([]string{"json", "console"}, c.Log.Format), "log.format", "must be json or console, got %q", c.Log.Format)
+
+	check(slices.Contains([]string{"none", "console", "otlp"}, c.Tracing.Exporter), "tracing.exporter",
+		"must be one of none, console, otlp, got %q", c.Tracing.Exporter)
+
+	_, err = ratelimit.ParseLimit(c.RateLimit.Default)
+	check(err == nil, "rateLimit.default", "%v", err)
+	for _, operation := range slices.Sorted(maps.Keys(c.RateLimit.Routes)) {
+		_, err := ratelimit.ParseLimit(c.RateLimit.Routes[operation])
+		check(err == nil, "rateLimit.routes."+operation, "%v", err)
+	}
+	check(slices.Contains([]string{"memory", "postgres"}, c.RateLimit.Store), "rateLimit.store", "must be memory or postgres, got %q", c.RateLimit.Store)
+
+	if c.Events.WebhookURL != "" {
+		target, err := url.Parse(c.Events.WebhookURL)
+		check(err == nil && (target.Scheme == "http" || target.Scheme == "https") && target.Host != "",
+			"events.webhookUrl", "must be an absolute http or https URL")
+	}
+	return errs
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n <= 65535
+}
+
+// CipherSuite returns the id of a secure cipher suite by its Go name, such as
+// TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256.
+func CipherSuite(name string) (uint16, error) {
+	for _, suite := range tls.CipherSuites() {
+		if suite.Name == name {
+			return suite.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown or insecure cipher suite %q", name)
+}
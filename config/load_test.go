@@ -0,0 +1,172 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setEnv clears every setting from the environment of the test, then sets env.
+func setEnv(t *testing.T, env map[string]string) {
+	t.Helper()
+	t.Setenv(FileEnv, "")
+	for _, s := range settings {
+		t.Setenv(s.env, "")
+	}
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// required are the settings without a default.
+var required = map[string]string{
+	"DB_HOST":    "localhost",
+	"DB_USER":    "petstore",
+	"DB_NAME":    "petstore",
+	"JWT_SECRET": "0123456789abcdef",
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := writeFile(t, `
+server:
+  addr: ":1000"
+  readTimeout: 1s
+log:
+  level: debug
+`)
+	tests := []struct {
+		name     string
+		env      map[string]string
+		args     []string
+		wantAddr string
+	}{
+		{"default", nil, nil, ":8080"},
+		{"file", nil, []string{"-config", file}, ":1000"},
+		{"file from env", map[string]string{FileEnv: file}, nil, ":1000"},
+		{"env over file", map[string]string{"HTTP_ADDR": ":2000"}, []string{"-config", file}, ":2000"},
+		{"flag over env", map[string]string{"HTTP_ADDR": ":2000"}, []string{"-config", file, "-addr", ":3000"}, ":3000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := map[string]string{}
+			for key, value := range required {
+				env[key] = value
+			}
+			for key, value := range tt.env {
+				env[key] = value
+			}
+			setEnv(t, env)
+
+			conf, err := Load(tt.args)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if conf.Server.Addr != tt.wantAddr {
+				t.Errorf("server.addr = %q, want %q", conf.Server.Addr, tt.wantAddr)
+			}
+			// Settings given at one level only keep their value.
+			if conf.Server.WriteTimeout != 10*time.Second {
+				t.Errorf("server.writeTimeout = %v, want the default", conf.Server.WriteTimeout)
+			}
+			if conf.DB.Host != "localhost" {
+				t.Errorf("db.host = %q, want localhost", conf.DB.Host)
+			}
+		})
+	}
+}
+
+func TestLoadValues(t *testing.T) {
+	setEnv(t, required)
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.1, 10.1.0.0/16,")
+	t.Setenv("RATE_LIMIT_ROUTES", "LoginUser=5/1m, GET /export/pets=1/1h")
+
+	conf, err := Load([]string{"-token-ttl", "2h", "-login-lockout-threshold", "3"})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := strings.Join(conf.Server.TrustedProxies, " "); got != "10.0.0.1 10.1.0.0/16" {
+		t.Errorf("server.trustedProxies = %q", got)
+	}
+	if conf.Auth.TokenTTL != 2*time.Hour || conf.Auth.LockoutThreshold != 3 {
+		t.Errorf("auth = %+v, want tokenTTL 2h and lockoutThreshold 3", conf.Auth)
+	}
+	routes := conf.RateLimit.Routes
+	if routes["LoginUser"] != "5/1m" || routes["GET /export/pets"] != "1/1h" {
+		t.Errorf("rateLimit.routes = %v, want the given limits", routes)
+	}
+	if routes["FindPetsByTags"] != "60/1m" {
+		t.Errorf("rateLimit.routes = %v, want the defaults kept", routes)
+	}
+}
+
+func TestLoadReportsAllErrors(t *testing.T) {
+	file := writeFile(t, `
+log:
+  format: xml
+`)
+	setEnv(t, map[string]string{
+		"DB_USER":           "petstore",
+		"DB_NAME":           "petstore",
+		"JWT_SECRET":        "short",
+		"HTTP_READ_TIMEOUT": "soon",
+		"TOKEN_TTL":         "-1h",
+	})
+
+	_, err := Load([]string{"-config", file, "-db-port", "0", "-rate-limit", "10/0s"})
+	if err == nil {
+		t.Fatal("Load succeeded, want an error")
+	}
+	for _, key := range []string{
+		"server.readTimeout: HTTP_READ_TIMEOUT",
+		"db.host",
+		"db.port",
+		"auth.jwtSecret",
+		"auth.tokenTTL",
+		"log.format",
+		"rateLimit.default",
+	} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("error does not mention %s:\n%v", key, err)
+		}
+	}
+}
+
+func TestLoadRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"unknown file key", []string{"-config", writeFile(t, "server:\n  adress: \":1000\"\n")}},
+		{"missing file", []string{"-config", filepath.Join(t.TempDir(), "missing.yaml")}},
+		{"unknown flag", []string{"-unknown"}},
+		{"argument", []string{"serve"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, required)
+			if _, err := Load(tt.args); err == nil {
+				t.Errorf("Load(%v) succeeded, want an error", tt.args)
+			}
+		})
+	}
+}
+
+func TestLoadHelp(t *testing.T) {
+	setEnv(t, required)
+	if _, err := Load([]string{"-h"}); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("Load(-h) = %v, want flag.ErrHelp", err)
+	}
+}
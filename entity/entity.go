@@ -1,6 +1,68 @@
 package entity
 
-import "swagger_petstore/petstore"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"swagger_petstore/petstore"
+	"time"
+)
+
+var (
+	ErrNotFound = errors.New("not found")
+	ErrConflict = errors.New("already exists")
+	ErrInvalid  = errors.New("invalid input")
+	// ErrPreconditionFailed is returned when the stored version differs from the expected one.
+	ErrPreconditionFailed = errors.New("version mismatch")
+	ErrUnauthorized       = errors.New("invalid username or password")
+	// ErrForbidden is returned when the role of the caller doesn't allow the action.
+	ErrForbidden = errors.New("forbidden")
+	// ErrTooManyAttempts is returned inside a RetryError while a client has to wait.
+	ErrTooManyAttempts = errors.New("too many attempts")
+)
+
+// RetryError tells the client to try again after a while.
+type RetryError struct {
+	Err   error
+	After time.Duration
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%v, retry in %s", e.Err, e.After.Round(time.Second))
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// Role grants a user access to the operations of its own role and of the roles below it.
+type Role string
+
+const (
+	RoleCustomer Role = "customer"
+	RoleStaff    Role = "staff"
+	RoleAdmin    Role = "admin"
+)
+
+var roleRanks = map[Role]int{RoleCustomer: 1, RoleStaff: 2, RoleAdmin: 3}
+
+func ParseRole(role string) (Role, error) {
+	if _, ok := roleRanks[Role(role)]; !ok {
+		return "", fmt.Errorf("%w: role must be one of customer, staff, admin", ErrInvalid)
+	}
+	return Role(role), nil
+}
+
+// AtLeast reports whether the role has the access of other.
+func (r Role) AtLeast(other Role) bool {
+	return roleRanks[r] >= roleRanks[other] && roleRanks[r] > 0
+}
+
+type UserRoleReq struct {
+	Role Role `json:"role" enums:"customer,staff,admin"`
+}
 
 type PetReq struct {
 	Id       *int64              `json:"id"`
@@ -9,3 +71,293 @@ type PetReq struct {
 	Tags     *[]petstore.Tag     `json:"tags"`
 	Status   *petstore.PetStatus `json:"status"`
 }
+
+type InventoryPoint struct {
+	Time     time.Time `json:"time" db:"bucket"`
+	Status   string    `json:"status" db:"status"`
+	Category string    `json:"category" db:"category"`
+	Count    int32     `json:"count" db:"count"`
+}
+
+type TagUsage struct {
+	Id   int64  `json:"id" db:"id"`
+	Name string `json:"name" db:"name"`
+	Pets int64  `json:"pets" db:"pets"`
+}
+
+type AuditEntry struct {
+	Id         int64           `json:"id" db:"id"`
+	EntityType string          `json:"entityType" db:"entity_type"`
+	EntityId   string          `json:"entityId" db:"entity_id"`
+	Action     string          `json:"action" db:"action"`
+	Actor      string          `json:"actor" db:"actor"`
+	ChangedAt  time.Time       `json:"changedAt" db:"changed_at"`
+	Before     json.RawMessage `json:"before,omitempty" db:"before" swaggertype:"object"`
+	After      json.RawMessage `json:"after,omitempty" db:"after" swaggertype:"object"`
+	Diff       json.RawMessage `json:"diff,omitempty" db:"diff" swaggertype:"object"`
+}
+
+type AuditFilter struct {
+	EntityType string
+	EntityId   string
+	Actor      string
+	Action     string
+	From       time.Time
+	To         time.Time
+	Limit      int
+	Offset     int
+}
+
+type PetSearch struct {
+	Query      string
+	Status     string
+	CategoryId int64
+	Tags       []string
+	Limit      int
+	Offset     int
+}
+
+const (
+	TagMatchAll = "all"
+	TagMatchAny = "any"
+)
+
+// TagQuery selects pets by tag names, ignoring case.
+type TagQuery struct {
+	Tags    []string
+	Exclude []string
+	Match   string
+}
+
+const (
+	ImportCreated = "created"
+	ImportValid   = "valid"
+	ImportFailed  = "failed"
+)
+
+type ImportResult struct {
+	Row    int    `json:"row"`
+	Id     *int64 `json:"id,omitempty"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type ImportReport struct {
+	DryRun  bool           `json:"dryRun"`
+	Created int            `json:"created"`
+	Valid   int            `json:"valid"`
+	Failed  int            `json:"failed"`
+	Rows    []ImportResult `json:"rows"`
+}
+
+type OrderFilter struct {
+	Status string
+	PetId  int64
+	From   time.Time
+	To     time.Time
+}
+
+type UserFilter struct {
+	UserStatus *int32
+}
+
+var (
+	PetRecordColumns   = []string{"id", "name", "status", "category", "tags", "photoUrls"}
+	OrderRecordColumns = []string{"id", "petId", "quantity", "shipDate", "status", "complete"}
+	UserRecordColumns  = []string{"id", "username", "firstName", "lastName", "email", "phone", "userStatus"}
+)
+
+// recordListSeparator joins tags and photo URLs in a CSV cell, as expected by the pet import.
+const recordListSeparator = "|"
+
+type PetRecord struct {
+	Id        int64    `json:"id"`
+	Name      string   `json:"name"`
+	Status    string   `json:"status"`
+	Category  string   `json:"category"`
+	Tags      []string `json:"tags"`
+	PhotoUrls []string `json:"photoUrls"`
+}
+
+func (r PetRecord) Fields() []string {
+	return []string{
+		strconv.FormatInt(r.Id, 10),
+		r.Name,
+		r.Status,
+		r.Category,
+		strings.Join(r.Tags, recordListSeparator),
+		strings.Join(r.PhotoUrls, recordListSeparator),
+	}
+}
+
+type OrderRecord struct {
+	Id       int64      `json:"id" db:"id"`
+	PetId    int64      `json:"petId" db:"petid"`
+	Quantity int32      `json:"quantity" db:"quantity"`
+	ShipDate *time.Time `json:"shipDate" db:"shipdate"`
+	Status   string     `json:"status" db:"status"`
+	Complete bool       `json:"complete" db:"complete"`
+}
+
+func (r OrderRecord) Fields() []string {
+	var shipDate string
+	if r.ShipDate != nil {
+		shipDate = r.ShipDate.Format(time.RFC3339)
+	}
+	return []string{
+		strconv.FormatInt(r.Id, 10),
+		strconv.FormatInt(r.PetId, 10),
+		strconv.FormatInt(int64(r.Quantity), 10),
+		shipDate,
+		r.Status,
+		strconv.FormatBool(r.Complete),
+	}
+}
+
+type UserRecord struct {
+	Id         int64  `json:"id" db:"id"`
+	Username   string `json:"username" db:"username"`
+	FirstName  string `json:"firstName" db:"firstname"`
+	LastName   string `json:"lastName" db:"lastname"`
+	Email      string `json:"email" db:"email"`
+	Phone      string `json:"phone" db:"phone"`
+	UserStatus int32  `json:"userStatus" db:"userstatus"`
+}
+
+func (r UserRecord) Fields() []string {
+	return []string{
+		strconv.FormatInt(r.Id, 10),
+		r.Username,
+		r.FirstName,
+		r.LastName,
+		r.Email,
+		r.Phone,
+		strconv.FormatInt(int64(r.UserStatus), 10),
+	}
+}
+
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	// JobDead marks a job that failed on its last attempt.
+	JobDead = "dead"
+)
+
+type Job struct {
+	Id          int64           `json:"id" db:"id"`
+	Type        string          `json:"type" db:"type"`
+	Payload     json.RawMessage `json:"-" db:"payload"`
+	Status      string          `json:"status" db:"status"`
+	Attempts    int             `json:"attempts" db:"attempts"`
+	MaxAttempts int             `json:"maxAttempts" db:"max_attempts"`
+	Progress    int             `json:"progress" db:"progress"`
+	Result      json.RawMessage `json:"result,omitempty" db:"result" swaggertype:"object"`
+	Error       string          `json:"error,omitempty" db:"last_error"`
+	RunAt       time.Time       `json:"runAt" db:"run_at"`
+	CreatedAt   time.Time       `json:"createdAt" db:"created_at"`
+	UpdatedAt   time.Time       `json:"updatedAt" db:"updated_at"`
+	FinishedAt  *time.Time      `json:"finishedAt,omitempty" db:"finished_at"`
+}
+
+const (
+	EventPetAdded         = "PetAdded"
+	EventPetStatusChanged = "PetStatusChanged"
+	EventOrderPlaced      = "OrderPlaced"
+	EventOrderCancelled   = "OrderCancelled"
+	EventUserRegistered   = "UserRegistered"
+	EventPetDeleted       = "PetDeleted"
+	EventPetRestored      = "PetRestored"
+	// EventInventoryChanged is derived from pet events for stream clients, it is not stored in the outbox.
+	EventInventoryChanged = "InventoryChanged"
+)
+
+// EventTypes lists the events stored in the outbox.
+var EventTypes = []string{
+	EventPetAdded,
+	EventPetStatusChanged,
+	EventPetDeleted,
+	EventPetRestored,
+	EventOrderPlaced,
+	EventOrderCancelled,
+	EventUserRegistered,
+}
+
+// Event is a domain event stored in the outbox together with the change that caused it.
+type Event struct {
+	Id         int64           `json:"id" db:"id"`
+	Type       string          `json:"type" db:"type"`
+	EntityId   string          `json:"entityId" db:"entity_id"`
+	Payload    json.RawMessage `json:"payload" db:"payload" swaggertype:"object"`
+	OccurredAt time.Time       `json:"occurredAt" db:"occurred_at"`
+	Attempts   int             `json:"-" db:"attempts"`
+	// Seq is the position of the event in the stream, it identifies Server-Sent Events.
+	Seq int64 `json:"-" db:"stream_seq"`
+}
+
+type PetStatusChange struct {
+	PetId int64  `json:"petId"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// PetPresence is the payload of PetDeleted and PetRestored events.
+type PetPresence struct {
+	PetId  int64  `json:"petId"`
+	Status string `json:"status"`
+}
+
+// InventoryDelta is the change of pet counts by status caused by one event.
+type InventoryDelta struct {
+	Delta map[string]int32 `json:"delta"`
+}
+
+type OrderCancellation struct {
+	OrderId int64 `json:"orderId"`
+	PetId   int64 `json:"petId"`
+}
+
+type UserRegistration struct {
+	Username string `json:"username"`
+	Email    string `json:"email,omitempty"`
+}
+
+type WebhookSubscription struct {
+	Id         int64     `json:"id"`
+	Url        string    `json:"url"`
+	EventTypes []string  `json:"eventTypes"`
+	Secret     string    `json:"secret,omitempty"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+type WebhookDelivery struct {
+	Id             int64           `json:"id" db:"id"`
+	SubscriptionId int64           `json:"subscriptionId" db:"subscription_id"`
+	EventId        int64           `json:"eventId" db:"event_id"`
+	EventType      string          `json:"eventType" db:"event_type"`
+	Payload        json.RawMessage `json:"payload" db:"payload" swaggertype:"object"`
+	Status         string          `json:"status" db:"status"`
+	Attempts       int             `json:"attempts" db:"attempts"`
+	NextAttemptAt  *time.Time      `json:"nextAttemptAt,omitempty" db:"next_attempt_at"`
+	ResponseStatus int             `json:"responseStatus,omitempty" db:"response_status"`
+	Error          string          `json:"error,omitempty" db:"last_error"`
+	CreatedAt      time.Time       `json:"createdAt" db:"created_at"`
+	DeliveredAt    *time.Time      `json:"deliveredAt,omitempty" db:"delivered_at"`
+}
+
+// LoginFailures counts the failed logins of a username or client IP, Key is "user:<name>"
+// or "ip:<addr>".
+type LoginFailures struct {
+	Key          string     `db:"key"`
+	Failures     int        `db:"failures"`
+	LastFailedAt time.Time  `db:"last_failed_at"`
+	LockedUntil  *time.Time `db:"locked_until"`
+}
@@ -0,0 +1,162 @@
+// Package event delivers domain events to sinks.
+package event
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"swagger_petstore/entity"
+	"swagger_petstore/tracing"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Sink receives published events. An error makes the outbox deliver the event again later,
+// so every sink must tolerate duplicates.
+type Sink interface {
+	Publish(ctx context.Context, event entity.Event) error
+}
+
+// LogSink writes every event to the log.
+type LogSink struct {
+	logger *zap.Logger
+}
+
+func NewLogSink(logger *zap.Logger) *LogSink {
+	return &LogSink{logger: logger}
+}
+
+func (s *LogSink) Publish(ctx context.Context, event entity.Event) error {
+	s.logger.Info("event published",
+		zap.Int64("event_id", event.Id),
+		zap.String("event_type", event.Type),
+		zap.String("entity_id", event.EntityId),
+		zap.ByteString("payload", event.Payload))
+	return nil
+}
+
+// WebhookSink posts every event as JSON to one URL.
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookSink(url string, timeout time.Duration) *WebhookSink {
+	return &WebhookSink{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (s *WebhookSink) Publish(ctx context.Context, event entity.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	tracing.Inject(ctx, req.Header)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return nil
+}
+
+// Bus passes events to subscribers in the same process. A subscriber that falls behind is
+// dropped instead of blocking the others: its channel is closed so it can catch up from the outbox.
+type Bus struct {
+	mu          sync.Mutex
+	subscribers map[chan entity.Event]struct{}
+}
+
+func NewBus() *Bus {
+	return &Bus{subscribers: map[chan entity.Event]struct{}{}}
+}
+
+func (b *Bus) Publish(ctx context.Context, event entity.Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+	return nil
+}
+
+// Subscribe returns a channel receiving events published from now on and a function
+// that cancels the subscription.
+func (b *Bus) Subscribe(buffer int) (<-chan entity.Event, func()) {
+	ch := make(chan entity.Event, buffer)
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Inventory returns the change of pet counts by status caused by the event, ok is false
+// for events that don't change the inventory.
+func Inventory(e entity.Event) (delta entity.InventoryDelta, ok bool, err error) {
+	delta.Delta = map[string]int32{}
+	add := func(status string, n int32) {
+		if status != "" {
+			delta.Delta[status] += n
+		}
+	}
+
+	switch e.Type {
+	case entity.EventPetAdded:
+		var pet struct {
+			Status string `json:"status"`
+		}
+		if err := json.Unmarshal(e.Payload, &pet); err != nil {
+			return delta, false, fmt.Errorf("invalid %s payload: %w", e.Type, err)
+		}
+		add(pet.Status, 1)
+	case entity.EventPetStatusChanged:
+		var change entity.PetStatusChange
+		if err := json.Unmarshal(e.Payload, &change); err != nil {
+			return delta, false, fmt.Errorf("invalid %s payload: %w", e.Type, err)
+		}
+		add(change.From, -1)
+		add(change.To, 1)
+	case entity.EventPetDeleted, entity.EventPetRestored:
+		var presence entity.PetPresence
+		if err := json.Unmarshal(e.Payload, &presence); err != nil {
+			return delta, false, fmt.Errorf("invalid %s payload: %w", e.Type, err)
+		}
+		if e.Type == entity.EventPetDeleted {
+			add(presence.Status, -1)
+		} else {
+			add(presence.Status, 1)
+		}
+	}
+
+	for status, n := range delta.Delta {
+		if n == 0 {
+			delete(delta.Delta, status)
+		}
+	}
+	return delta, len(delta.Delta) > 0, nil
+}
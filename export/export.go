@@ -0,0 +1,67 @@
+// Package export writes records as CSV or JSON Lines.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+const (
+	CSV    = "csv"
+	NDJSON = "ndjson"
+)
+
+// Record is a row of an export, Fields returns its CSV cells.
+type Record interface {
+	Fields() []string
+}
+
+// Writer encodes records in one format, CSV output starts with the header.
+type Writer struct {
+	csv  *csv.Writer
+	json *json.Encoder
+}
+
+func NewWriter(w io.Writer, format string, header []string) (*Writer, error) {
+	switch format {
+	case CSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(header); err != nil {
+			return nil, err
+		}
+		return &Writer{csv: writer}, nil
+	case NDJSON:
+		return &Writer{json: json.NewEncoder(w)}, nil
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+func (w *Writer) Write(record Record) error {
+	if w.csv != nil {
+		return w.csv.Write(record.Fields())
+	}
+	return w.json.Encode(record)
+}
+
+// Flush writes buffered CSV rows to the underlying writer.
+func (w *Writer) Flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		return w.csv.Error()
+	}
+	return nil
+}
+
+// Valid reports whether format is supported.
+func Valid(format string) bool {
+	return format == CSV || format == NDJSON
+}
+
+func ContentType(format string) string {
+	if format == CSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
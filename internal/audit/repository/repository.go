@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"swagger_petstore/entity"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type AuditRepository interface {
+	AddEntry(ctx context.Context, entry entity.AuditEntry) error
+	FindEntries(ctx context.Context, filter entity.AuditFilter) ([]entity.AuditEntry, error)
+}
+type Repository struct {
+	db *sqlx.DB
+}
+
+func NewAuditRepository(db *sqlx.DB) AuditRepository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) AddEntry(ctx context.Context, entry entity.AuditEntry) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO audit_log (entity_type, entity_id, action, actor, before, after, diff)
+		VALUES ($1, $2, $3, $4, $5::jsonb, $6::jsonb, $7::jsonb)`,
+		entry.EntityType, entry.EntityId, entry.Action, entry.Actor,
+		jsonb(entry.Before), jsonb(entry.After), jsonb(entry.Diff),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add audit entry: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) FindEntries(ctx context.Context, filter entity.AuditFilter) ([]entity.AuditEntry, error) {
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.Replace(condition, "?", "$"+strconv.Itoa(len(args)), 1))
+	}
+
+	if filter.EntityType != "" {
+		add("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityId != "" {
+		add("entity_id = ?", filter.EntityId)
+	}
+	if filter.Actor != "" {
+		add("actor = ?", filter.Actor)
+	}
+	if filter.Action != "" {
+		add("action = ?", filter.Action)
+	}
+	if !filter.From.IsZero() {
+		add("changed_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		add("changed_at < ?", filter.To)
+	}
+
+	// NULL can't be scanned into json.RawMessage, so it is read back as JSON null.
+	query := `SELECT id, entity_type, entity_id, action, actor, changed_at,
+			 COALESCE(before, 'null') AS before, COALESCE(after, 'null') AS after, COALESCE(diff, 'null') AS diff
+			 FROM audit_log`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit, filter.Offset)
+	query += fmt.Sprintf(" ORDER BY changed_at, id LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	entries := []entity.AuditEntry{}
+	err := r.db.SelectContext(ctx, &entries, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find audit entries: %w", err)
+	}
+	return entries, nil
+}
+
+// jsonb passes raw JSON as text so it can be cast to jsonb, keeping empty values NULL.
+func jsonb(raw []byte) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"swagger_petstore/entity"
+	"swagger_petstore/internal/audit/repository"
+	"swagger_petstore/logging"
+	"swagger_petstore/middleware"
+
+	"go.uber.org/zap"
+)
+
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+	ActionLock    = "lock"
+	ActionUnlock  = "unlock"
+
+	EntityPet   = "pet"
+	EntityOrder = "order"
+	EntityUser  = "user"
+	// EntityClient is a client IP, locked out after too many failed logins.
+	EntityClient = "client"
+
+	anonymous = "anonymous"
+
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+// Recorder is used by the domain services to write the audit trail.
+type Recorder interface {
+	Record(ctx context.Context, entityType, entityId, action string, before, after interface{})
+}
+
+type Servicer interface {
+	Recorder
+	GetHistory(ctx context.Context, entityType, entityId string) ([]entity.AuditEntry, error)
+	FindEntries(ctx context.Context, filter entity.AuditFilter) ([]entity.AuditEntry, error)
+}
+type AuditService struct {
+	repository repository.AuditRepository
+}
+
+func NewAuditService(repository repository.AuditRepository) *AuditService {
+	return &AuditService{repository: repository}
+}
+
+// Record appends a change made by the user of the request. The change itself is
+// already stored, so a failure is logged instead of being returned to the caller.
+func (s *AuditService) Record(ctx context.Context, entityType, entityId, action string, before, after interface{}) {
+	actor := middleware.UserID(ctx)
+	if actor == "" {
+		actor = anonymous
+	}
+
+	entry := entity.AuditEntry{
+		EntityType: entityType,
+		EntityId:   entityId,
+		Action:     action,
+		Actor:      actor,
+	}
+
+	var err error
+	if entry.Before, err = marshal(before); err == nil {
+		if entry.After, err = marshal(after); err == nil {
+			entry.Diff, err = diff(entry.Before, entry.After)
+		}
+	}
+	if err == nil {
+		err = s.repository.AddEntry(context.WithoutCancel(ctx), entry)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("audit: failed to record change",
+			zap.String("entity_type", entityType),
+			zap.String("entity_id", entityId),
+			zap.String("action", action),
+			zap.Error(err))
+	}
+}
+
+func (s *AuditService) GetHistory(ctx context.Context, entityType, entityId string) ([]entity.AuditEntry, error) {
+	return s.FindEntries(ctx, entity.AuditFilter{EntityType: entityType, EntityId: entityId, Limit: maxLimit})
+}
+
+func (s *AuditService) FindEntries(ctx context.Context, filter entity.AuditFilter) ([]entity.AuditEntry, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultLimit
+	}
+	if filter.Limit > maxLimit {
+		return nil, fmt.Errorf("%w: limit must not exceed %d", entity.ErrInvalid, maxLimit)
+	}
+	if filter.Offset < 0 {
+		return nil, fmt.Errorf("%w: offset must not be negative", entity.ErrInvalid)
+	}
+	return s.repository.FindEntries(ctx, filter)
+}
+
+func marshal(value interface{}) (json.RawMessage, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil, nil
+	}
+	return json.Marshal(value)
+}
+
+// diff returns the top level fields that changed as {"field": {"before": ..., "after": ...}}.
+func diff(before, after json.RawMessage) (json.RawMessage, error) {
+	var old, updated map[string]interface{}
+	if len(before) > 0 {
+		if err := json.Unmarshal(before, &old); err != nil {
+			return nil, err
+		}
+	}
+	if len(after) > 0 {
+		if err := json.Unmarshal(after, &updated); err != nil {
+			return nil, err
+		}
+	}
+
+	changes := map[string]map[string]interface{}{}
+	for key, value := range old {
+		if !reflect.DeepEqual(value, updated[key]) {
+			changes[key] = map[string]interface{}{"before": value, "after": updated[key]}
+		}
+	}
+	for key, value := range updated {
+		if _, ok := old[key]; !ok {
+			changes[key] = map[string]interface{}{"before": nil, "after": value}
+		}
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(changes)
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+	"swagger_petstore/entity"
+	"swagger_petstore/tracing"
+)
+
+// tracedService starts a span around the methods of the service that can fail.
+type tracedService struct {
+	Servicer
+}
+
+// Traced wraps the service so that its calls are traced.
+func Traced(service Servicer) Servicer {
+	return tracedService{Servicer: service}
+}
+
+func (s tracedService) GetHistory(ctx context.Context, entityType, entityId string) (_ []entity.AuditEntry, err error) {
+	ctx, span := tracing.Start(ctx, "AuditService.GetHistory")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.GetHistory(ctx, entityType, entityId)
+}
+
+func (s tracedService) FindEntries(ctx context.Context, filter entity.AuditFilter) (_ []entity.AuditEntry, err error) {
+	ctx, span := tracing.Start(ctx, "AuditService.FindEntries")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.FindEntries(ctx, filter)
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"swagger_petstore/entity"
+	"swagger_petstore/petstore"
+	"swagger_petstore/postgres"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type CategoriesRepository interface {
+	CreateCategory(ctx context.Context, name string) (petstore.Category, error)
+	GetCategories(ctx context.Context) ([]petstore.Category, error)
+	GetCategoryById(ctx context.Context, categoryId int64) (petstore.Category, error)
+	UpdateCategory(ctx context.Context, categoryId int64, name string) error
+	DeleteCategory(ctx context.Context, categoryId int64) error
+}
+type Repository struct {
+	db *sqlx.DB
+}
+
+func NewCategoryRepository(db *sqlx.DB) CategoriesRepository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) CreateCategory(ctx context.Context, name string) (petstore.Category, error) {
+	var id int64
+	err := r.db.QueryRowxContext(ctx,
+		"INSERT INTO categories (name) VALUES ($1) RETURNING id", name,
+	).Scan(&id)
+	if postgres.IsUniqueViolation(err) {
+		return petstore.Category{}, fmt.Errorf("category %q: %w", name, entity.ErrConflict)
+	}
+	if err != nil {
+		return petstore.Category{}, fmt.Errorf("failed to create category: %w", err)
+	}
+	return petstore.Category{Id: &id, Name: &name}, nil
+}
+
+func (r *Repository) GetCategories(ctx context.Context) ([]petstore.Category, error) {
+	categories := []petstore.Category{}
+	err := r.db.SelectContext(ctx, &categories, "SELECT id, name FROM categories ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	return categories, nil
+}
+
+func (r *Repository) GetCategoryById(ctx context.Context, categoryId int64) (petstore.Category, error) {
+	var category petstore.Category
+	err := r.db.GetContext(ctx, &category, "SELECT id, name FROM categories WHERE id = $1", categoryId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return petstore.Category{}, fmt.Errorf("category %d: %w", categoryId, entity.ErrNotFound)
+	}
+	if err != nil {
+		return petstore.Category{}, fmt.Errorf("failed to get category by ID: %w", err)
+	}
+	return category, nil
+}
+
+func (r *Repository) UpdateCategory(ctx context.Context, categoryId int64, name string) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE categories SET name = $1 WHERE id = $2", name, categoryId)
+	if postgres.IsUniqueViolation(err) {
+		return fmt.Errorf("category %q: %w", name, entity.ErrConflict)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update category: %w", err)
+	}
+	return expectAffected(res, categoryId)
+}
+
+// DeleteCategory removes the category, pets that used it are left without a category.
+func (r *Repository) DeleteCategory(ctx context.Context, categoryId int64) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM categories WHERE id = $1", categoryId)
+	if err != nil {
+		return fmt.Errorf("failed to delete category: %w", err)
+	}
+	return expectAffected(res, categoryId)
+}
+
+func expectAffected(res sql.Result, categoryId int64) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("category %d: %w", categoryId, entity.ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"swagger_petstore/entity"
+	"swagger_petstore/internal/category/repository"
+	petRepository "swagger_petstore/internal/pet/repository"
+	"swagger_petstore/petstore"
+)
+
+type Servicer interface {
+	CreateCategory(ctx context.Context, category petstore.Category) (petstore.Category, error)
+	GetCategories(ctx context.Context) ([]petstore.Category, error)
+	GetCategoryById(ctx context.Context, categoryId int64) (petstore.Category, error)
+	UpdateCategory(ctx context.Context, categoryId int64, category petstore.Category) error
+	DeleteCategory(ctx context.Context, categoryId int64) error
+	FindPetsByCategory(ctx context.Context, categoryId int64) ([]petstore.Pet, error)
+}
+type CategoryService struct {
+	pRepository petRepository.PetsRepository
+	repository  repository.CategoriesRepository
+}
+
+func NewCategoryService(pRepository petRepository.PetsRepository, repository repository.CategoriesRepository) *CategoryService {
+	return &CategoryService{pRepository: pRepository, repository: repository}
+}
+
+func (s *CategoryService) CreateCategory(ctx context.Context, category petstore.Category) (petstore.Category, error) {
+	name, err := categoryName(category)
+	if err != nil {
+		return petstore.Category{}, err
+	}
+	return s.repository.CreateCategory(ctx, name)
+}
+
+func (s *CategoryService) GetCategories(ctx context.Context) ([]petstore.Category, error) {
+	return s.repository.GetCategories(ctx)
+}
+
+func (s *CategoryService) GetCategoryById(ctx context.Context, categoryId int64) (petstore.Category, error) {
+	if categoryId <= 0 {
+		return petstore.Category{}, fmt.Errorf("%w: invalid categoryId: must be a positive number", entity.ErrInvalid)
+	}
+	return s.repository.GetCategoryById(ctx, categoryId)
+}
+
+func (s *CategoryService) UpdateCategory(ctx context.Context, categoryId int64, category petstore.Category) error {
+	if categoryId <= 0 {
+		return fmt.Errorf("%w: invalid categoryId: must be a positive number", entity.ErrInvalid)
+	}
+	name, err := categoryName(category)
+	if err != nil {
+		return err
+	}
+	return s.repository.UpdateCategory(ctx, categoryId, name)
+}
+
+func (s *CategoryService) DeleteCategory(ctx context.Context, categoryId int64) error {
+	if categoryId <= 0 {
+		return fmt.Errorf("%w: invalid categoryId: must be a positive number", entity.ErrInvalid)
+	}
+	return s.repository.DeleteCategory(ctx, categoryId)
+}
+
+func (s *CategoryService) FindPetsByCategory(ctx context.Context, categoryId int64) ([]petstore.Pet, error) {
+	if _, err := s.GetCategoryById(ctx, categoryId); err != nil {
+		return nil, err
+	}
+	return s.pRepository.FindPetsByCategory(ctx, categoryId)
+}
+
+func categoryName(category petstore.Category) (string, error) {
+	if category.Name == nil || strings.TrimSpace(*category.Name) == "" {
+		return "", fmt.Errorf("%w: category name required", entity.ErrInvalid)
+	}
+	return strings.TrimSpace(*category.Name), nil
+}
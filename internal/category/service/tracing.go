@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"swagger_petstore/petstore"
+	"swagger_petstore/tracing"
+)
+
+// tracedService starts a span around the methods of the service that can fail.
+type tracedService struct {
+	Servicer
+}
+
+// Traced wraps the service so that its calls are traced.
+func Traced(service Servicer) Servicer {
+	return tracedService{Servicer: service}
+}
+
+func (s tracedService) CreateCategory(ctx context.Context, category petstore.Category) (_ petstore.Category, err error) {
+	ctx, span := tracing.Start(ctx, "CategoryService.CreateCategory")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.CreateCategory(ctx, category)
+}
+
+func (s tracedService) GetCategories(ctx context.Context) (_ []petstore.Category, err error) {
+	ctx, span := tracing.Start(ctx, "CategoryService.GetCategories")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.GetCategories(ctx)
+}
+
+func (s tracedService) GetCategoryById(ctx context.Context, categoryId int64) (_ petstore.Category, err error) {
+	ctx, span := tracing.Start(ctx, "CategoryService.GetCategoryById")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.GetCategoryById(ctx, categoryId)
+}
+
+func (s tracedService) UpdateCategory(ctx context.Context, categoryId int64, category petstore.Category) (err error) {
+	ctx, span := tracing.Start(ctx, "CategoryService.UpdateCategory")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.UpdateCategory(ctx, categoryId, category)
+}
+
+func (s tracedService) DeleteCategory(ctx context.Context, categoryId int64) (err error) {
+	ctx, span := tracing.Start(ctx, "CategoryService.DeleteCategory")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.DeleteCategory(ctx, categoryId)
+}
+
+func (s tracedService) FindPetsByCategory(ctx context.Context, categoryId int64) (_ []petstore.Pet, err error) {
+	ctx, span := tracing.Start(ctx, "CategoryService.FindPetsByCategory")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.FindPetsByCategory(ctx, categoryId)
+}
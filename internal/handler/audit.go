@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"swagger_petstore/entity"
+	aService "swagger_petstore/internal/audit/service"
+)
+
+// @Summary			pet history
+// @Security 		ApiKeyAuth
+// @Description		changes made to the pet, oldest first
+// @Tags			pet
+// @Accept			json
+// @Produce			json
+// @Param			petId   path	int	true  "pet id"
+// @Success			200		{object}	ResponseAudit
+// @Router			/pet/{petId}/history [get]
+func (A *API) GetPetHistory(w http.ResponseWriter, r *http.Request) {
+	petId, err := parseID(r, "petId")
+	if err != nil {
+		A.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	entries, err := A.auditService.GetHistory(r.Context(), aService.EntityPet, strconv.FormatInt(petId, 10))
+	if err != nil {
+		A.respondError(w, err)
+		return
+	}
+
+	A.responder.OutputJSON(w, ResponseAudit{
+		Success: true,
+		Data: AuditData{
+			Entries: entries,
+		},
+	})
+}
+
+// @Summary			audit log
+// @Security 		ApiKeyAuth
+// @Description		query the audit log of pets, orders and users
+// @Tags			audit
+// @Accept			json
+// @Produce			json
+// @Param			entity_type	query	string	false	"entity type" Enums(pet,order,user,client)
+// @Param			entity_id	query	string	false	"entity id, username for users"
+// @Param			actor		query	string	false	"user that made the change"
+// @Param			action		query	string	false	"action" Enums(create,update,delete,restore,lock,unlock)
+// @Param			from		query	string	false	"changed at or after (RFC3339 or YYYY-MM-DD)"
+// @Param			to			query	string	false	"changed before (RFC3339 or YYYY-MM-DD)"
+// @Param			limit		query	int		false	"page size, 100 by default"
+// @Param			offset		query	int		false	"entries to skip"
+// @Success			200		{object}	ResponseAudit
+// @Router			/audit [get]
+func (A *API) FindAuditEntries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := entity.AuditFilter{
+		EntityType: query.Get("entity_type"),
+		EntityId:   query.Get("entity_id"),
+		Actor:      query.Get("actor"),
+		Action:     query.Get("action"),
+	}
+
+	var err error
+	if filter.From, err = parseTime(query.Get("from")); err != nil {
+		A.responder.ErrorBadRequest(w, fmt.Errorf("invalid from: %w", err))
+		return
+	}
+	if filter.To, err = parseTime(query.Get("to")); err != nil {
+		A.responder.ErrorBadRequest(w, fmt.Errorf("invalid to: %w", err))
+		return
+	}
+	if filter.Limit, err = parseInt(query.Get("limit")); err != nil {
+		A.responder.ErrorBadRequest(w, fmt.Errorf("invalid limit: %w", err))
+		return
+	}
+	if filter.Offset, err = parseInt(query.Get("offset")); err != nil {
+		A.responder.ErrorBadRequest(w, fmt.Errorf("invalid offset: %w", err))
+		return
+	}
+
+	entries, err := A.auditService.FindEntries(r.Context(), filter)
+	if err != nil {
+		A.respondError(w, err)
+		return
+	}
+
+	A.responder.OutputJSON(w, ResponseAudit{
+		Success: true,
+		Data: AuditData{
+			Entries: entries,
+		},
+	})
+}
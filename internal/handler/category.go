@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"swagger_petstore/petstore"
+)
+
+// @Summary			create category
+// @Security 		ApiKeyAuth
+// @Description		create category
+// @Tags			category
+// @Accept			json
+// @Produce			json
+// @Param			category   body	petstore.Category	true  "create category"
+// @Success			200		{object}	ResponseCategory
+// @Router			/category [post]
+func (A *API) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	var category petstore.Category
+	if err := json.NewDecoder(r.Body).Decode(&category); err != nil {
+		A.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	category, err := A.categoryService.CreateCategory(r.Context(), category)
+	if err != nil {
+		A.respondError(w, err)
+		return
+	}
+
+	A.responder.OutputJSON(w, ResponseCategory{
+		Success: true,
+		Data: CategoryData{
+			Message:  fmt.Sprintf("added a new category %s", *category.Name),
+			Category: category,
+		},
+	})
+}
+
+// @Summary			list categories
+// @Security 		ApiKeyAuth
+// @Description		list categories
+// @Tags			category
+// @Accept			json
+// @Produce			json
+// @Success			200		{object}	ResponseCategories
+// @Router			/category [get]
+func (A *API) GetCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := A.categoryService.GetCategories(r.Context())
+	if err != nil {
+		A.respondError(w, err)
+		return
+	}
+
+	A.responder.OutputJSON(w, ResponseCategories{
+		Success: true,
+		Data: CategoriesData{
+			Categories: categories,
+		},
+	})
+}
+
+// @Summary			find category by id
+// @Security 		ApiKeyAuth
+// @Description		get category
+// @Tags			category
+// @Accept			json
+// @Produce			json
+// @Param			categoryId   path	int	true  "category id"
+// @Success			200		{object}	ResponseCategory
+// @Router			/category/{categoryId} [get]
+func (A *API) GetCategoryById(w http.ResponseWriter, r *http.Request) {
+	categoryId, err := parseID(r, "categoryId")
+	if err != nil {
+		A.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	category, err := A.categoryService.GetCategoryById(r.Context(), categoryId)
+	if err != nil {
+		A.respondError(w, err)
+		return
+	}
+
+	A.responder.OutputJSON(w, ResponseCategory{
+		Success: true,
+		Data: CategoryData{
+			Category: category,
+		},
+	})
+}
+
+// @Summary			update category
+// @Security 		ApiKeyAuth
+// @Description		rename category
+// @Tags			category
+// @Accept			json
+// @Produce			json
+// @Param			categoryId   path	int					true  "category id"
+// @Param			category     body	petstore.Category	true  "update category"
+// @Success			200		{object}	ResponseData
+// @Router			/category/{categoryId} [put]
+func (A *API) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	categoryId, err := parseID(r, "categoryId")
+	if err != nil {
+		A.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	var category petstore.Category
+	if err := json.NewDecoder(r.Body).Decode(&category); err != nil {
+		A.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	err = A.categoryService.UpdateCategory(r.Context(), categoryId, category)
+	if err != nil {
+		A.respondError(w, err)
+		return
+	}
+
+	A.responder.OutputJSON(w, ResponseData{
+		Success: true,
+		Data: Data{
+			Message: fmt.Sprintf("category number %d has been updated", categoryId),
+		},
+	})
+}
+
+// @Summary			delete category
+// @Security 		ApiKeyAuth
+// @Description		delete category, its pets are left without a category
+// @Tags			category
+// @Accept			json
+// @Produce			json
+// @Param			categoryId   path	int	true  "category id"
+// @Success			200		{object}	ResponseData
+// @Router			/category/{categoryId} [delete]
+func (A *API) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	categoryId, err := parseID(r, "categoryId")
+	if err != nil {
+		A.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	err = A.categoryService.DeleteCategory(r.Context(), categoryId)
+	if err != nil {
+		A.respondError(w, err)
+		return
+	}
+
+	A.responder.OutputJSON(w, ResponseData{
+		Success: true,
+		Data: Data{
+			Message: fmt.Sprintf("category number %d has been deleted", categoryId),
+		},
+	})
+}
+
+// @Summary			find pets by category
+// @Security 		ApiKeyAuth
+// @Description		list pets of a category
+// @Tags			category
+// @Accept			json
+// @Produce			json
+// @Param			categoryId   path	int	true  "category id"
+// @Success			200		{object}	ResponsePets
+// @Router			/category/{categoryId}/pets [get]
+func (A *API) FindPetsByCategory(w http.ResponseWriter, r *http.Request) {
+	categoryId, err := parseID(r, "categoryId")
+	if err != nil {
+		A.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	pets, err := A.categoryService.FindPetsByCategory(r.Context(), categoryId)
+	if err != nil {
+		A.respondError(w, err)
+		return
+	}
+
+	A.responder.OutputJSON(w, ResponsePets{
+		Success: true,
+		Data: PetsData{
+			Pets: pets,
+		},
+	})
+}
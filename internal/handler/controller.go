@@ -3,33 +3,56 @@ package handler
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+	"io"
+	"mime"
 	"net/http"
+	"strconv"
+	"swagger_petstore/entity"
+	aService "swagger_petstore/internal/audit/service"
+	cService "swagger_petstore/internal/category/service"
+	jService "swagger_petstore/internal/job/service"
 	oService "swagger_petstore/internal/order/service"
+	obService "swagger_petstore/internal/outbox/service"
 	pService "swagger_petstore/internal/pet/service"
+	tService "swagger_petstore/internal/tag/service"
 	uService "swagger_petstore/internal/user/service"
+	wService "swagger_petstore/internal/webhook/service"
+	"swagger_petstore/mergepatch"
 	"swagger_petstore/middleware"
 	"swagger_petstore/petstore"
 	"swagger_petstore/responder"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/jwtauth/v5"
 	"github.com/golang-jwt/jwt/v5"
 )
 
 type API struct {
-	responder    responder.Responder
-	userService  uService.Servicer
-	petService   pService.Servicer
-	orderService oService.Servicer
+	responder       responder.Responder
+	userService     uService.Servicer
+	petService      pService.Servicer
+	orderService    oService.Servicer
+	categoryService cService.Servicer
+	tagService      tService.Servicer
+	auditService    aService.Servicer
+	jobService      jService.Servicer
+	webhookService  wService.Servicer
+	outboxService   obService.Servicer
 }
 
-func NewAPI(responder responder.Responder, userService uService.Servicer, petService pService.Servicer, orderService oService.Servicer) *API {
+func NewAPI(responder responder.Responder, userService uService.Servicer, petService pService.Servicer, orderService oService.Servicer, categoryService cService.Servicer, tagService tService.Servicer, auditService aService.Servicer, jobService jService.Servicer, webhookService wService.Servicer, outboxService obService.Servicer) *API {
 	return &API{
-		responder:    responder,
-		userService:  userService,
-		petService:   petService,
-		orderService: orderService,
+		responder:       responder,
+		userService:     userService,
+		petService:      petService,
+		orderService:    orderService,
+		categoryService: categoryService,
+		tagService:      tagService,
+		auditService:    auditService,
+		jobService:      jobService,
+		webhookService:  webhookService,
+		outboxService:   outboxService,
 	}
 }
 
@@ -45,13 +68,13 @@ func NewAPI(responder responder.Responder, userService uService.Servicer, petSer
 func (A *API) AddPet(w http.ResponseWriter, r *http.Request) {
 	var pet petstore.Pet
 	if err := json.NewDecoder(r.Body).Decode(&pet); err != nil {
-		log.Fatal(err)
+		A.responder.ErrorBadRequest(w, err)
 		return
 	}
 
 	err := A.petService.AddPet(r.Context(), pet)
 	if err != nil {
-		A.responder.ErrorInternal(w, err)
+		A.respondError(w, err)
 		return
 	}
 	A.responder.OutputJSON(w, ResponseData{
@@ -69,18 +92,25 @@ func (A *API) AddPet(w http.ResponseWriter, r *http.Request) {
 // @Accept			json
 // @Produce			json
 // @Param			pet   body	petstore.Pet	true  "update pet"
+// @Param			If-Match	header	string	false	"ETag of the pet being replaced"
 // @Success			200		{object}	ResponseData
 // @Router			/pet [put]
 func (A *API) UpdatePet(w http.ResponseWriter, r *http.Request) {
 	var pet petstore.Pet
 	if err := json.NewDecoder(r.Body).Decode(&pet); err != nil {
-		log.Fatal(err)
+		A.responder.ErrorBadRequest(w, err)
 		return
 	}
 
-	err := A.petService.UpdatePet(r.Context(), pet)
+	version, err := ifMatchVersion(r)
 	if err != nil {
-		A.responder.ErrorInternal(w, err)
+		A.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	err = A.petService.UpdatePet(r.Context(), pet, version)
+	if err != nil {
+		A.respondError(w, err)
 		return
 	}
 
@@ -92,6 +122,58 @@ func (A *API) UpdatePet(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// @Summary			patch pet
+// @Security 		ApiKeyAuth
+// @Description		partial update of a pet with a JSON Merge Patch (RFC 7396)
+// @Tags			pet
+// @Accept			application/merge-patch+json
+// @Produce			json
+// @Param			petId   path	int				true  "pet id"
+// @Param			pet     body	petstore.Pet	true  "fields to change, null removes a field"
+// @Param			If-Match	header	string	false	"ETag of the pet being patched"
+// @Success			200		{object}	ResponsePet
+// @Router			/pet/{petId} [patch]
+func (A *API) PatchPet(w http.ResponseWriter, r *http.Request) {
+	petId, err := parseID(r, "petId")
+	if err != nil {
+		A.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != mergepatch.ContentType {
+		A.responder.ErrorUnsupportedMediaType(w, fmt.Errorf("content type must be %s", mergepatch.ContentType))
+		return
+	}
+
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		A.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		A.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	pet, version, err := A.petService.PatchPet(r.Context(), petId, version, patch)
+	if err != nil {
+		A.respondError(w, err)
+		return
+	}
+	setETag(w, version)
+
+	A.responder.OutputJSON(w, ResponsePet{
+		Success: true,
+		Data: PetData{
+			Message: fmt.Sprintf("pet number %d has been patched", petId),
+			Pet:     pet,
+		},
+	})
+}
+
 // @Summary			find pets by status
 // @Security 		ApiKeyAuth
 // @Description		find pet
@@ -99,12 +181,13 @@ func (A *API) UpdatePet(w http.ResponseWriter, r *http.Request) {
 // @Accept			json
 // @Produce			json
 // @Param			status   query	[]string	true  "Status values that need to be considered for filter" Enums(available,pending,sold)
+// @Param			include_deleted	query	bool	false	"include soft-deleted pets, requires an admin token"
 // @Success			200		{object}	ResponsePets
 // @Router			/pet/findByStatus [get]
 func (A *API) FindPetsByStatus(w http.ResponseWriter, r *http.Request, params petstore.FindPetsByStatusParams) {
-	pets, err := A.petService.FindPetsByStatus(r.Context(), params)
+	pets, err := A.petService.FindPetsByStatus(r.Context(), params, includeDeleted(r))
 	if err != nil {
-		A.responder.ErrorInternal(w, err)
+		A.respondError(w, err)
 		return
 	}
 
@@ -122,13 +205,77 @@ func (A *API) FindPetsByStatus(w http.ResponseWriter, r *http.Request, params pe
 // @Tags			pet
 // @Accept			json
 // @Produce			json
-// @Param			tags   query	[]string	true  "find pet by tags"
+// @Param			tags   query	[]string	false  "find pet by tags, case-insensitive"
+// @Param			match	query	string	false	"pet must have all (default) or any of the tags" Enums(all,any)
+// @Param			exclude	query	[]string	false	"pet must have none of these tags"
+// @Param			include_deleted	query	bool	false	"include soft-deleted pets, requires an admin token"
 // @Success			200		{object}	ResponsePets
 // @Router			/pet/findByTags [get]
 func (A *API) FindPetsByTags(w http.ResponseWriter, r *http.Request, params petstore.FindPetsByTagsParams) {
-	pets, err := A.petService.FindPetsByTags(r.Context(), params)
+	query := r.URL.Query()
+	tags := entity.TagQuery{
+		Exclude: query["exclude"],
+		Match:   query.Get("match"),
+	}
+	if params.Tags != nil {
+		tags.Tags = *params.Tags
+	}
+
+	pets, err := A.petService.FindPetsByTags(r.Context(), tags, includeDeleted(r))
 	if err != nil {
-		A.responder.ErrorInternal(w, err)
+		A.respondError(w, err)
+		return
+	}
+
+	A.responder.OutputJSON(w, ResponsePets{
+		Success: true,
+		Data: PetsData{
+			Pets: pets,
+		},
+	})
+}
+
+// @Summary			search pets
+// @Security		ApiKeyAuth
+// @Description		full-text and fuzzy search over pet name, category and tags, best matches first
+// @Tags			pet
+// @Accept			json
+// @Produce			json
+// @Param			q			query	string		true	"search text"
+// @Param			status		query	string		false	"pet status" Enums(available,pending,sold)
+// @Param			categoryId	query	int			false	"category id"
+// @Param			tags		query	[]string	false	"pets must have all of the tags"
+// @Param			limit		query	int			false	"page size, 50 by default"
+// @Param			offset		query	int			false	"results to skip"
+// @Success			200		{object}	ResponsePets
+// @Router			/pet/search [get]
+func (A *API) SearchPets(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	search := entity.PetSearch{
+		Query:  query.Get("q"),
+		Status: query.Get("status"),
+		Tags:   query["tags"],
+	}
+
+	var err error
+	if categoryId := query.Get("categoryId"); categoryId != "" {
+		if search.CategoryId, err = strconv.ParseInt(categoryId, 10, 64); err != nil {
+			A.responder.ErrorBadRequest(w, fmt.Errorf("invalid categoryId: %w", err))
+			return
+		}
+	}
+	if search.Limit, err = parseInt(query.Get("limit")); err != nil {
+		A.responder.ErrorBadRequest(w, fmt.Errorf("invalid limit: %w", err))
+		return
+	}
+	if search.Offset, err = parseInt(query.Get("offset")); err != nil {
+		A.responder.ErrorBadRequest(w, fmt.Errorf("invalid offset: %w", err))
+		return
+	}
+
+	pets, err := A.petService.SearchPets(r.Context(), search)
+	if err != nil {
+		A.respondError(w, err)
 		return
 	}
 
@@ -148,6 +295,7 @@ func (A *API) FindPetsByTags(w http.ResponseWriter, r *http.Request, params pets
 // @Produce			json
 // @Param			petId   path	int	true  " "
 // @Param			params   header	petstore.DeletePetParams	true  "delete pet"
+// @Param			If-Match	header	string	false	"ETag of the pet being deleted"
 // @Success			200		{object}	ResponseData
 // @Router			/pet/{petId} [delete]
 func (A *API) DeletePet(w http.ResponseWriter, r *http.Request, petId int64, params petstore.DeletePetParams) {
@@ -155,9 +303,15 @@ func (A *API) DeletePet(w http.ResponseWriter, r *http.Request, petId int64, par
 		return
 	}
 
-	err := A.petService.DeletePet(r.Context(), petId, params)
+	version, err := ifMatchVersion(r)
 	if err != nil {
-		A.responder.ErrorInternal(w, err)
+		A.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	err = A.petService.DeletePet(r.Context(), petId, params, version)
+	if err != nil {
+		A.respondError(w, err)
 		return
 	}
 
@@ -176,12 +330,17 @@ func (A *API) DeletePet(w http.ResponseWriter, r *http.Request, petId int64, par
 // @Accept			json
 // @Produce			json
 // @Param			petId   path	int	true  "pet id"
+// @Param			If-None-Match	header	string	false	"ETag of the cached pet"
+// @Param			include_deleted	query	bool	false	"also find a soft-deleted pet, requires an admin token"
 // @Success			200		{object}	ResponsePet
 // @Router			/pet/{petId} [get]
 func (A *API) GetPetById(w http.ResponseWriter, r *http.Request, petId int64) {
-	pet, err := A.petService.GetPetById(r.Context(), petId)
+	pet, version, err := A.petService.GetVersionedPet(r.Context(), petId, includeDeleted(r))
 	if err != nil {
-		A.responder.ErrorInternal(w, err)
+		A.respondError(w, err)
+		return
+	}
+	if notModified(w, r, version) {
 		return
 	}
 
@@ -194,6 +353,36 @@ func (A *API) GetPetById(w http.ResponseWriter, r *http.Request, petId int64) {
 	})
 }
 
+// @Summary			restore pet
+// @Security		ApiKeyAuth
+// @Description		restore a soft-deleted pet
+// @Tags			pet
+// @Accept			json
+// @Produce			json
+// @Param			petId   path	int	true  "pet id"
+// @Success			200		{object}	ResponseData
+// @Router			/pet/{petId}/restore [post]
+func (A *API) RestorePet(w http.ResponseWriter, r *http.Request) {
+	petId, err := parseID(r, "petId")
+	if err != nil {
+		A.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	err = A.petService.RestorePet(r.Context(), petId)
+	if err != nil {
+		A.respondError(w, err)
+		return
+	}
+
+	A.responder.OutputJSON(w, ResponseData{
+		Success: true,
+		Data: Data{
+			Message: fmt.Sprintf("pet number %d has been restored", petId),
+		},
+	})
+}
+
 // @Summary			update pet with form
 // @Security 		ApiKeyAuth
 // @Description		update
@@ -206,14 +395,23 @@ func (A *API) GetPetById(w http.ResponseWriter, r *http.Request, petId int64) {
 // @Success			200		{object}	ResponseData
 // @Router			/pet/{petId} [post]
 func (A *API) UpdatePetWithForm(w http.ResponseWriter, r *http.Request, petId int64, params petstore.UpdatePetWithFormParams) {
-	name := r.FormValue("name")
-	status := r.FormValue("status")
-	params.Name = &name
-	params.Status = &status
+	if err := r.ParseForm(); err != nil {
+		A.responder.ErrorBadRequest(w, err)
+		return
+	}
+	// Only the fields sent in the form are updated, the query parameters stay as a fallback.
+	if r.PostForm.Has("name") {
+		name := r.PostForm.Get("name")
+		params.Name = &name
+	}
+	if r.PostForm.Has("status") {
+		status := r.PostForm.Get("status")
+		params.Status = &status
+	}
 
 	err := A.petService.UpdatePetWithForm(r.Context(), petId, params)
 	if err != nil {
-		A.responder.ErrorInternal(w, err)
+		A.respondError(w, err)
 		return
 	}
 
@@ -254,8 +452,47 @@ func (A *API) GetInventory(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// @Summary			get inventory history
+// @Security 		ApiKeyAuth
+// @Description		inventory snapshots by status and category over time
+// @Tags			store
+// @Accept			json
+// @Produce			json
+// @Param			from		query	string	false	"start of the range (RFC3339 or YYYY-MM-DD), defaults to 30 days before to"
+// @Param			to			query	string	false	"end of the range (RFC3339 or YYYY-MM-DD), defaults to now"
+// @Param			interval	query	string	false	"bucket size" Enums(hour,day,week,month)
+// @Success			200		{object}	ResponseInventoryHistory
+// @Router			/store/inventory/history [get]
+func (A *API) GetInventoryHistory(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	from, err := parseTime(query.Get("from"))
+	if err != nil {
+		A.responder.ErrorBadRequest(w, fmt.Errorf("invalid from: %w", err))
+		return
+	}
+	to, err := parseTime(query.Get("to"))
+	if err != nil {
+		A.responder.ErrorBadRequest(w, fmt.Errorf("invalid to: %w", err))
+		return
+	}
+
+	history, err := A.orderService.GetInventoryHistory(r.Context(), from, to, query.Get("interval"))
+	if err != nil {
+		A.respondError(w, err)
+		return
+	}
+
+	A.responder.OutputJSON(w, ResponseInventoryHistory{
+		Success: true,
+		Data: InventoryHistoryData{
+			History: history,
+		},
+	})
+}
+
 // @Summary			place order
-// @Description		create order
+// @Security		ApiKeyAuth
+// @Description		create an order owned by the caller
 // @Tags			store
 // @Accept			json
 // @Produce			json
@@ -270,7 +507,7 @@ func (A *API) PlaceOrder(w http.ResponseWriter, r *http.Request) {
 	}
 	err := A.orderService.PlaceOrder(r.Context(), order)
 	if err != nil {
-		A.responder.ErrorInternal(w, err)
+		A.respondError(w, err)
 		return
 	}
 	A.responder.OutputJSON(w, ResponseOrder{
@@ -283,17 +520,25 @@ func (A *API) PlaceOrder(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary			delete order
-// @Description		delete
+// @Security		ApiKeyAuth
+// @Description		delete, customers may only delete their own orders
 // @Tags			store
 // @Accept			json
 // @Produce			json
 // @Param			orderId   path	int	true  "id"
+// @Param			If-Match	header	string	false	"ETag of the order being deleted"
 // @Success			200		{object}	ResponseData
 // @Router			/store/order/{orderId} [delete]
 func (A *API) DeleteOrder(w http.ResponseWriter, r *http.Request, orderId int64) {
-	err := A.orderService.DeleteOrder(r.Context(), orderId)
+	version, err := ifMatchVersion(r)
 	if err != nil {
-		A.responder.ErrorInternal(w, err)
+		A.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	err = A.orderService.DeleteOrder(r.Context(), orderId, version)
+	if err != nil {
+		A.respondError(w, err)
 		return
 	}
 
@@ -306,17 +551,22 @@ func (A *API) DeleteOrder(w http.ResponseWriter, r *http.Request, orderId int64)
 }
 
 // @Summary			get order
-// @Description		get
+// @Security		ApiKeyAuth
+// @Description		get, customers may only get their own orders
 // @Tags			store
 // @Accept			json
 // @Produce			json
 // @Param			orderId   path	int	true  "id"
+// @Param			If-None-Match	header	string	false	"ETag of the cached order"
 // @Success			200		{object}	ResponseOrder
 // @Router			/store/order/{orderId} [get]
 func (A *API) GetOrderById(w http.ResponseWriter, r *http.Request, orderId int64) {
-	order, err := A.orderService.GetOrderById(r.Context(), orderId)
+	order, version, err := A.orderService.GetVersionedOrder(r.Context(), orderId)
 	if err != nil {
-		A.responder.ErrorInternal(w, err)
+		A.respondError(w, err)
+		return
+	}
+	if notModified(w, r, version) {
 		return
 	}
 
@@ -387,7 +637,7 @@ func (A *API) CreateUsersWithListInput(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary			login user
-// @Description		auth
+// @Description		auth, repeated failures slow down and then temporarily lock the username and the client IP
 // @Tags			user
 // @Accept			json
 // @Produce			json
@@ -397,7 +647,7 @@ func (A *API) CreateUsersWithListInput(w http.ResponseWriter, r *http.Request) {
 func (A *API) LoginUser(w http.ResponseWriter, r *http.Request, params petstore.LoginUserParams) {
 	token, err := A.userService.LoginUser(r.Context(), params)
 	if err != nil {
-		A.responder.ErrorInternal(w, err)
+		A.respondError(w, err)
 		return
 	}
 
@@ -424,7 +674,7 @@ func (A *API) LogoutUser(w http.ResponseWriter, r *http.Request) {
 
 	token, _, err := jwt.NewParser().ParseUnverified(tokenString, jwt.MapClaims{})
 	if err != nil {
-		fmt.Printf("Ошибка парсинга токена: %v\n", err)
+		A.responder.ErrorUnauthorized(w, fmt.Errorf("failed to parse token: %w", err))
 		return
 	}
 
@@ -456,22 +706,30 @@ func (A *API) LogoutUser(w http.ResponseWriter, r *http.Request) {
 			},
 		})
 	} else {
-		fmt.Println("Не удалось извлечь claims из токена")
+		A.responder.ErrorUnauthorized(w, fmt.Errorf("failed to read token claims"))
 	}
 }
 
 // @Summary			delete user
-// @Description		delete
+// @Security		ApiKeyAuth
+// @Description		delete, only admins may delete another user
 // @Tags			user
 // @Accept			json
 // @Produce			json
 // @Param			username   path	string	true  "name"
+// @Param			If-Match	header	string	false	"ETag of the user being deleted"
 // @Success			200		{object}	ResponseData
 // @Router			/user/{username} [delete]
 func (A *API) DeleteUser(w http.ResponseWriter, r *http.Request, username string) {
-	err := A.userService.DeleteUser(r.Context(), username)
+	version, err := ifMatchVersion(r)
 	if err != nil {
-		A.responder.ErrorInternal(w, err)
+		A.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	err = A.userService.DeleteUser(r.Context(), username, version)
+	if err != nil {
+		A.respondError(w, err)
 		return
 	}
 
@@ -483,18 +741,104 @@ func (A *API) DeleteUser(w http.ResponseWriter, r *http.Request, username string
 	})
 }
 
+// @Summary			restore user
+// @Security		ApiKeyAuth
+// @Description		restore a soft-deleted user
+// @Tags			user
+// @Accept			json
+// @Produce			json
+// @Param			username   path	string	true  "name"
+// @Success			200		{object}	ResponseData
+// @Router			/user/{username}/restore [post]
+func (A *API) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	username := chi.URLParam(r, "username")
+	err := A.userService.RestoreUser(r.Context(), username)
+	if err != nil {
+		A.respondError(w, err)
+		return
+	}
+
+	A.responder.OutputJSON(w, ResponseData{
+		Success: true,
+		Data: Data{
+			Message: fmt.Sprintf("user %s has been restored", username),
+		},
+	})
+}
+
+// @Summary			unlock user
+// @Security		ApiKeyAuth
+// @Description		lift the lockout of a username after failed logins
+// @Tags			user
+// @Accept			json
+// @Produce			json
+// @Param			username   path	string	true  "name"
+// @Success			200		{object}	ResponseData
+// @Router			/user/{username}/unlock [post]
+func (A *API) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	username := chi.URLParam(r, "username")
+	if err := A.userService.UnlockUser(r.Context(), username); err != nil {
+		A.respondError(w, err)
+		return
+	}
+
+	A.responder.OutputJSON(w, ResponseData{
+		Success: true,
+		Data: Data{
+			Message: fmt.Sprintf("user %s has been unlocked", username),
+		},
+	})
+}
+
+// @Summary			set user role
+// @Security		ApiKeyAuth
+// @Description		assign the customer, staff or admin role, admins can't change their own role
+// @Tags			user
+// @Accept			json
+// @Produce			json
+// @Param			username   path	string				true  "name"
+// @Param			role	   body	entity.UserRoleReq	true  "role"
+// @Success			200		{object}	ResponseData
+// @Router			/user/{username}/role [put]
+func (A *API) SetUserRole(w http.ResponseWriter, r *http.Request) {
+	username := chi.URLParam(r, "username")
+	var req entity.UserRoleReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		A.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	if err := A.userService.SetUserRole(r.Context(), username, req.Role); err != nil {
+		A.respondError(w, err)
+		return
+	}
+
+	A.responder.OutputJSON(w, ResponseData{
+		Success: true,
+		Data: Data{
+			Message: fmt.Sprintf("user %s is now %s", username, req.Role),
+		},
+	})
+}
+
 // @Summary			get user by name
 // @Description		get user
 // @Tags			user
 // @Accept			json
 // @Produce			json
 // @Param			username	path	string	true "get user"
+// @Param			If-None-Match	header	string	false	"ETag of the cached user"
+// @Param			include_deleted	query	bool	false	"also find a soft-deleted user, requires an admin token"
 // @Success			200		{object}	ResponseUser
 // @Router			/user/{username} [get]
 func (A *API) GetUserByName(w http.ResponseWriter, r *http.Request, username string) {
-	user, err := A.userService.GetUserByName(r.Context(), username)
+	withDeleted := includeDeleted(A.withPrincipal(r))
+	user, version, err := A.userService.GetVersionedUser(r.Context(), username, withDeleted)
 	if err != nil {
-		A.responder.ErrorInternal(w, err)
+		A.respondError(w, err)
+		return
+	}
+	if notModified(w, r, version) {
 		return
 	}
 
@@ -507,12 +851,14 @@ func (A *API) GetUserByName(w http.ResponseWriter, r *http.Request, username str
 }
 
 // @Summary			update user
-// @Description		update
+// @Security		ApiKeyAuth
+// @Description		update, only admins may update another user, the username can't be changed
 // @Tags			user
 // @Accept			json
 // @Produce			json
 // @Param			username	path string	true "username"
 // @Param			user		body petstore.User	true "user"
+// @Param			If-Match	header	string	false	"ETag of the user being replaced"
 // @Success			200			{object}	ResponseData
 // @Router			/user/{username} [put]
 func (A *API) UpdateUser(w http.ResponseWriter, r *http.Request, username string) {
@@ -521,7 +867,6 @@ func (A *API) UpdateUser(w http.ResponseWriter, r *http.Request, username string
 		A.responder.ErrorBadRequest(w, err)
 		return
 	}
-	fmt.Println(username)
 	_, err := A.userService.GetUserByName(r.Context(), username)
 	if err != nil {
 		A.responder.OutputJSON(w, ErrorResponse{
@@ -534,9 +879,15 @@ func (A *API) UpdateUser(w http.ResponseWriter, r *http.Request, username string
 		return
 	}
 
-	err = A.userService.UpdateUser(r.Context(), user)
+	version, err := ifMatchVersion(r)
 	if err != nil {
-		A.responder.ErrorInternal(w, err)
+		A.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	err = A.userService.UpdateUser(r.Context(), username, user, version)
+	if err != nil {
+		A.respondError(w, err)
 		return
 	}
 
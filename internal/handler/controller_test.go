@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"swagger_petstore/config"
+	"swagger_petstore/entity"
+	pRepository "swagger_petstore/internal/pet/repository"
+	pService "swagger_petstore/internal/pet/service"
+	uRepository "swagger_petstore/internal/user/repository"
+	uService "swagger_petstore/internal/user/service"
+	"swagger_petstore/middleware"
+	"swagger_petstore/petstore"
+	"swagger_petstore/responder"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/ptflp/godecoder"
+	"go.uber.org/zap"
+)
+
+// fakeUsers stores one user, with a password as the row in the database has.
+type fakeUsers struct {
+	uRepository.UsersRepository
+	user petstore.User
+}
+
+func (r *fakeUsers) GetVersionedUser(_ context.Context, username string, _ bool) (petstore.User, int64, error) {
+	if r.user.Username == nil || *r.user.Username != username {
+		return petstore.User{}, 0, entity.ErrNotFound
+	}
+	return r.user, 1, nil
+}
+
+type nopRecorder struct{}
+
+func (nopRecorder) Record(context.Context, string, string, string, interface{}, interface{}) {}
+
+func newUserAPI(user petstore.User) http.Handler {
+	middleware.SetSecret("0123456789abcdef")
+	respond := responder.NewResponder(godecoder.NewDecoder(jsoniter.Config{}), zap.NewNop())
+	users := uService.NewUserService(&fakeUsers{user: user}, nopRecorder{}, config.AuthConfig{})
+	api := NewAPI(respond, users, nil, nil, nil, nil, nil, nil, nil, nil)
+	return petstore.HandlerWithOptions(api, petstore.ChiServerOptions{})
+}
+
+func TestGetUserByNameHidesPassword(t *testing.T) {
+	username, password, email := "bob", "s3cret", "bob@example.com"
+	h := newUserAPI(petstore.User{Username: &username, Password: &password, Email: &email})
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/user/bob", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+	}
+
+	var body struct {
+		Data struct {
+			User map[string]interface{} `json:"user"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid response %s: %v", w.Body, err)
+	}
+	if body.Data.User["username"] != username || body.Data.User["email"] != email {
+		t.Errorf("user = %v, want bob", body.Data.User)
+	}
+	if _, ok := body.Data.User["password"]; ok {
+		t.Errorf("anonymous response carries the password: %s", w.Body)
+	}
+}
+
+// fakePets keeps the parameters of the last form update.
+type fakePets struct {
+	pRepository.PetsRepository
+	form *petstore.UpdatePetWithFormParams
+}
+
+func (r *fakePets) GetVersionedPet(context.Context, int64, bool) (petstore.Pet, int64, error) {
+	return petstore.Pet{}, 0, entity.ErrNotFound
+}
+
+func (r *fakePets) UpdatePetWithForm(_ context.Context, petId int64, params petstore.UpdatePetWithFormParams) error {
+	if petId != 7 {
+		return entity.ErrNotFound
+	}
+	r.form = &params
+	return nil
+}
+
+func TestUpdatePetWithForm(t *testing.T) {
+	respond := responder.NewResponder(godecoder.NewDecoder(jsoniter.Config{}), zap.NewNop())
+	pets := &fakePets{}
+	api := NewAPI(respond, nil, pService.PetService(pets, nopRecorder{}), nil, nil, nil, nil, nil, nil, nil)
+	h := petstore.HandlerWithOptions(api, petstore.ChiServerOptions{})
+
+	post := func(path string, form url.Values) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := post("/pet/7", url.Values{"status": {"sold"}}); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+	}
+	if pets.form == nil || pets.form.Name != nil || pets.form.Status == nil || *pets.form.Status != "sold" {
+		t.Errorf("form = %+v, want the status only", pets.form)
+	}
+
+	if w := post("/pet/8", url.Values{"name": {"rex"}}); w.Code != http.StatusNotFound {
+		t.Errorf("missing pet: status = %d, want 404", w.Code)
+	}
+	if w := post("/pet/7", url.Values{"status": {"lost"}}); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid status: status = %d, want 400", w.Code)
+	}
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"swagger_petstore/entity"
+	"swagger_petstore/event"
+	"swagger_petstore/server"
+	"sync"
+	"time"
+)
+
+const (
+	// eventsHeartbeat keeps idle streams open through proxies.
+	eventsHeartbeat = 15 * time.Second
+	// eventsRetry is the reconnection delay suggested to clients, in milliseconds.
+	eventsRetry = 3000
+)
+
+// sseMessage is one Server-Sent Events message.
+type sseMessage struct {
+	event string
+	data  interface{}
+}
+
+// @Summary			stream events
+// @Security 		ApiKeyAuth
+// @Description		Server-Sent Events stream of domain events as they are committed, before they reach the other sinks. InventoryChanged messages carry the change of pet counts by status, apply them to GET /store/inventory. Messages are identified by the position of their domain event in the stream; reconnecting with Last-Event-ID replays the events missed since then
+// @Tags			events
+// @Produce			text/event-stream
+// @Param			types			query	[]string	false	"event types to receive, all by default" Enums(PetAdded,PetStatusChanged,PetDeleted,PetRestored,OrderPlaced,OrderCancelled,UserRegistered,InventoryChanged)
+// @Param			Last-Event-ID	header	int			false	"id of the last message received"
+// @Success			200		{string}	string
+// @Router			/events [get]
+func (A *API) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	types := r.URL.Query()["types"]
+	for _, eventType := range types {
+		if eventType != entity.EventInventoryChanged && !slices.Contains(entity.EventTypes, eventType) {
+			A.responder.ErrorBadRequest(w, fmt.Errorf("unknown event type %q", eventType))
+			return
+		}
+	}
+	wants := func(eventType string) bool {
+		return len(types) == 0 || slices.Contains(types, eventType)
+	}
+
+	afterSeq := int64(-1)
+	if lastEventId := r.Header.Get("Last-Event-ID"); lastEventId != "" {
+		var err error
+		if afterSeq, err = strconv.ParseInt(lastEventId, 10, 64); err != nil || afterSeq < 0 {
+			A.responder.ErrorBadRequest(w, fmt.Errorf("invalid Last-Event-ID: %q", lastEventId))
+			return
+		}
+	}
+
+	controller := http.NewResponseController(w)
+	// The stream stays open far longer than the server write timeout.
+	_ = controller.SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// Heartbeats are written concurrently with events.
+	var mu sync.Mutex
+	write := func(write func(w io.Writer) error) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if err := write(w); err != nil {
+			return err
+		}
+		return controller.Flush()
+	}
+	if err := write(func(w io.Writer) error {
+		_, err := fmt.Fprintf(w, "retry: %d\n\n", eventsRetry)
+		return err
+	}); err != nil {
+		return
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(eventsHeartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				_ = write(func(w io.Writer) error {
+					_, err := io.WriteString(w, ": heartbeat\n\n")
+					return err
+				})
+			}
+		}
+	}()
+
+	// The stream ends when the server shuts down, the client reconnects to another instance.
+	ctx, cancel := server.UntilDrain(r.Context())
+	defer cancel()
+
+	// An error ends the stream, the client reconnects with the id of the last event it got.
+	_ = A.outboxService.Stream(ctx, afterSeq, func(e entity.Event) error {
+		var messages []sseMessage
+		if wants(e.Type) {
+			messages = append(messages, sseMessage{event: e.Type, data: e})
+		}
+		if wants(entity.EventInventoryChanged) {
+			if delta, ok, err := event.Inventory(e); err == nil && ok {
+				messages = append(messages, sseMessage{event: entity.EventInventoryChanged, data: delta})
+			}
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+
+		return write(func(w io.Writer) error {
+			for i, message := range messages {
+				data, err := json.Marshal(message.data)
+				if err != nil {
+					return err
+				}
+				// Only the last message carries the id, so a client resuming after it
+				// doesn't skip the rest of the event.
+				if i == len(messages)-1 {
+					if _, err := fmt.Fprintf(w, "id: %d\n", e.Seq); err != nil {
+						return err
+					}
+				}
+				if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", message.event, data); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
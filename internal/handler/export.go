@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"swagger_petstore/entity"
+	"swagger_petstore/export"
+	"time"
+)
+
+// exportFlushEvery is the number of records sent to the client at once.
+const exportFlushEvery = 500
+
+// @Summary			export pets
+// @Security 		ApiKeyAuth
+// @Description		stream active pets as CSV or JSON Lines, the CSV can be imported again with POST /pet/import
+// @Tags			export
+// @Produce			text/csv
+// @Produce			application/x-ndjson
+// @Param			format		query	string		false	"output format, csv by default" Enums(csv,ndjson)
+// @Param			q			query	string		false	"search text"
+// @Param			status		query	string		false	"pet status" Enums(available,pending,sold)
+// @Param			categoryId	query	int			false	"category id"
+// @Param			tags		query	[]string	false	"pets must have all of the tags"
+// @Success			200		{string}	string
+// @Router			/export/pets [get]
+func (A *API) ExportPets(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	search := entity.PetSearch{
+		Query:  query.Get("q"),
+		Status: query.Get("status"),
+		Tags:   query["tags"],
+	}
+	if categoryId := query.Get("categoryId"); categoryId != "" {
+		var err error
+		if search.CategoryId, err = strconv.ParseInt(categoryId, 10, 64); err != nil {
+			A.responder.ErrorBadRequest(w, fmt.Errorf("invalid categoryId: %w", err))
+			return
+		}
+	}
+
+	A.stream(w, r, "pets", entity.PetRecordColumns, func(write func(record export.Record) error) error {
+		return A.petService.ExportPets(r.Context(), search, func(pet entity.PetRecord) error {
+			return write(pet)
+		})
+	})
+}
+
+// @Summary			export orders
+// @Security 		ApiKeyAuth
+// @Description		stream orders as CSV or JSON Lines
+// @Tags			export
+// @Produce			text/csv
+// @Produce			application/x-ndjson
+// @Param			format	query	string	false	"output format, csv by default" Enums(csv,ndjson)
+// @Param			status	query	string	false	"order status" Enums(placed,approved,delivered)
+// @Param			petId	query	int		false	"pet id"
+// @Param			from	query	string	false	"shipped at or after (RFC3339 or YYYY-MM-DD)"
+// @Param			to		query	string	false	"shipped before (RFC3339 or YYYY-MM-DD)"
+// @Success			200		{string}	string
+// @Router			/export/orders [get]
+func (A *API) ExportOrders(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := entity.OrderFilter{
+		Status: query.Get("status"),
+	}
+
+	var err error
+	if petId := query.Get("petId"); petId != "" {
+		if filter.PetId, err = strconv.ParseInt(petId, 10, 64); err != nil {
+			A.responder.ErrorBadRequest(w, fmt.Errorf("invalid petId: %w", err))
+			return
+		}
+	}
+	if filter.From, err = parseTime(query.Get("from")); err != nil {
+		A.responder.ErrorBadRequest(w, fmt.Errorf("invalid from: %w", err))
+		return
+	}
+	if filter.To, err = parseTime(query.Get("to")); err != nil {
+		A.responder.ErrorBadRequest(w, fmt.Errorf("invalid to: %w", err))
+		return
+	}
+
+	A.stream(w, r, "orders", entity.OrderRecordColumns, func(write func(record export.Record) error) error {
+		return A.orderService.ExportOrders(r.Context(), filter, func(order entity.OrderRecord) error {
+			return write(order)
+		})
+	})
+}
+
+// @Summary			export users
+// @Security 		ApiKeyAuth
+// @Description		stream active users without passwords as CSV or JSON Lines
+// @Tags			export
+// @Produce			text/csv
+// @Produce			application/x-ndjson
+// @Param			format		query	string	false	"output format, csv by default" Enums(csv,ndjson)
+// @Param			userStatus	query	int		false	"user status"
+// @Success			200		{string}	string
+// @Router			/export/users [get]
+func (A *API) ExportUsers(w http.ResponseWriter, r *http.Request) {
+	var filter entity.UserFilter
+	if value := r.URL.Query().Get("userStatus"); value != "" {
+		userStatus, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			A.responder.ErrorBadRequest(w, fmt.Errorf("invalid userStatus: %w", err))
+			return
+		}
+		status := int32(userStatus)
+		filter.UserStatus = &status
+	}
+
+	A.stream(w, r, "users", entity.UserRecordColumns, func(write func(record export.Record) error) error {
+		return A.userService.ExportUsers(r.Context(), filter, func(user entity.UserRecord) error {
+			return write(user)
+		})
+	})
+}
+
+// stream writes the records produced by run as an attachment. The response starts with the
+// first record, so errors returned before it are reported as usual; a later error aborts
+// the response to keep a truncated file from looking complete.
+func (A *API) stream(w http.ResponseWriter, r *http.Request, name string, header []string, run func(write func(record export.Record) error) error) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = export.CSV
+	}
+	if !export.Valid(format) {
+		A.responder.ErrorBadRequest(w, fmt.Errorf("invalid format: must be one of csv, ndjson"))
+		return
+	}
+
+	controller := http.NewResponseController(w)
+	var writer *export.Writer
+	started := false
+	start := func() error {
+		if started {
+			return nil
+		}
+		started = true
+		// A large export takes longer than the server write timeout.
+		_ = controller.SetWriteDeadline(time.Time{})
+
+		filename := fmt.Sprintf("%s-%s.%s", name, time.Now().UTC().Format("20060102-150405"), format)
+		w.Header().Set("Content-Type", export.ContentType(format))
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		w.WriteHeader(http.StatusOK)
+
+		var err error
+		writer, err = export.NewWriter(w, format, header)
+		return err
+	}
+
+	written := 0
+	err := run(func(record export.Record) error {
+		if err := start(); err != nil {
+			return err
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+		written++
+		if written%exportFlushEvery == 0 {
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+			return controller.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = start()
+	}
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err != nil {
+		if !started {
+			A.respondError(w, err)
+			return
+		}
+		panic(http.ErrAbortHandler)
+	}
+}
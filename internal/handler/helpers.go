@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"swagger_petstore/authz"
+	"swagger_petstore/entity"
+	"swagger_petstore/middleware"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// respondError maps domain errors to the matching status code.
+func (A *API) respondError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, entity.ErrNotFound):
+		A.responder.ErrorNotFound(w, err)
+	case errors.Is(err, entity.ErrConflict):
+		A.responder.ErrorConflict(w, err)
+	case errors.Is(err, entity.ErrInvalid):
+		A.responder.ErrorBadRequest(w, err)
+	case errors.Is(err, entity.ErrPreconditionFailed):
+		A.responder.ErrorPreconditionFailed(w, err)
+	case errors.Is(err, entity.ErrForbidden):
+		A.responder.ErrorForbidden(w, err)
+	case errors.Is(err, entity.ErrUnauthorized):
+		A.responder.ErrorUnauthorized(w, err)
+	case errors.Is(err, entity.ErrTooManyAttempts):
+		var retry *entity.RetryError
+		if errors.As(err, &retry) {
+			w.Header().Set("Retry-After", strconv.FormatInt(int64(math.Ceil(retry.After.Seconds())), 10))
+		}
+		A.responder.ErrorTooManyRequests(w, err)
+	default:
+		A.responder.ErrorInternal(w, err)
+	}
+}
+
+func parseID(r *http.Request, name string) (int64, error) {
+	id, err := strconv.ParseInt(chi.URLParam(r, name), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return id, nil
+}
+
+// includeDeleted reports whether the caller asked for soft-deleted rows with ?include_deleted=true,
+// which admins only may see.
+func includeDeleted(r *http.Request) bool {
+	include, _ := strconv.ParseBool(r.URL.Query().Get("include_deleted"))
+	return include && authz.RequireRole(r.Context(), entity.RoleAdmin) == nil
+}
+
+// withPrincipal finds the principal of an optional token on the public routes, which
+// the authz.Authorizer doesn't guard.
+func (A *API) withPrincipal(r *http.Request) *http.Request {
+	username := middleware.TokenUserID(r)
+	if username == "" {
+		return r
+	}
+	role, err := A.userService.GetUserRole(r.Context(), username)
+	if err != nil {
+		return r
+	}
+	return r.WithContext(authz.NewContext(r.Context(), authz.Principal{Username: username, Role: role}))
+}
+
+func parseBool(value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+func parseInt(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
+
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+// ifMatchVersion returns the version expected by the If-Match header,
+// zero when the header is absent or "*".
+func ifMatchVersion(r *http.Request) (int64, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+	version, err := strconv.ParseInt(strings.Trim(strings.TrimPrefix(value, "W/"), `"`), 10, 64)
+	if err != nil || version <= 0 {
+		return 0, fmt.Errorf("invalid If-Match header %q", value)
+	}
+	return version, nil
+}
+
+func setETag(w http.ResponseWriter, version int64) {
+	w.Header().Set("ETag", fmt.Sprintf(`"%d"`, version))
+}
+
+// notModified sets the ETag and answers 304 when If-None-Match already holds the current version.
+func notModified(w http.ResponseWriter, r *http.Request, version int64) bool {
+	setETag(w, version)
+
+	current := fmt.Sprintf(`"%d"`, version)
+	for _, tag := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == current || tag == "*" {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	pService "swagger_petstore/internal/pet/service"
+)
+
+// maxImportSize limits the size of an import file.
+const maxImportSize = 32 << 20
+
+var importFormats = map[string]string{
+	"text/csv":             pService.ImportCSV,
+	"application/x-ndjson": pService.ImportNDJSON,
+	"application/ndjson":   pService.ImportNDJSON,
+	"application/jsonl":    pService.ImportNDJSON,
+}
+
+// @Summary			import pets
+// @Security 		ApiKeyAuth
+// @Description		bulk import of pets from CSV with a header row (name,status,category,tags,photoUrls; tags and photoUrls separated by |)
+// @Description		or from JSON Lines with the same fields, tags and photoUrls as arrays. Every row is reported as created, valid (dry run) or failed.
+// @Tags			pet
+// @Accept			text/csv
+// @Accept			application/x-ndjson
+// @Produce			json
+// @Param			file	body	string	true	"CSV or JSON Lines"
+// @Param			dry_run	query	bool	false	"validate and report without saving"
+// @Param			async	query	bool	false	"run as a background job, poll the job from the Location header for the report"
+// @Success			200		{object}	ResponseImport
+// @Success			202		{object}	ResponseJob
+// @Router			/pet/import [post]
+func (A *API) ImportPets(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	format, ok := importFormats[mediaType]
+	if !ok {
+		A.responder.ErrorUnsupportedMediaType(w, fmt.Errorf("content type must be text/csv or application/x-ndjson"))
+		return
+	}
+
+	dryRun, err := parseBool(r.URL.Query().Get("dry_run"))
+	if err != nil {
+		A.responder.ErrorBadRequest(w, fmt.Errorf("invalid dry_run: %w", err))
+		return
+	}
+	async, err := parseBool(r.URL.Query().Get("async"))
+	if err != nil {
+		A.responder.ErrorBadRequest(w, fmt.Errorf("invalid async: %w", err))
+		return
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxImportSize)
+	if async {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			A.responder.ErrorBadRequest(w, err)
+			return
+		}
+		// An import is not idempotent, a retry would create the pets of committed batches again.
+		job, err := A.jobService.Enqueue(r.Context(), pService.ImportJob, pService.ImportPayload{
+			Format: format,
+			DryRun: dryRun,
+			Data:   string(data),
+		}, 1)
+		if err != nil {
+			A.respondError(w, err)
+			return
+		}
+		A.acceptJob(w, job)
+		return
+	}
+
+	report, err := A.petService.ImportPets(r.Context(), body, format, dryRun)
+	if err != nil {
+		A.respondError(w, err)
+		return
+	}
+
+	A.responder.OutputJSON(w, ResponseImport{
+		Success: report.Failed == 0,
+		Data: ImportData{
+			Message: fmt.Sprintf("%d created, %d valid, %d failed", report.Created, report.Valid, report.Failed),
+			Report:  report,
+		},
+	})
+}
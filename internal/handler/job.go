@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"swagger_petstore/entity"
+	jService "swagger_petstore/internal/job/service"
+	pService "swagger_petstore/internal/pet/service"
+)
+
+// @Summary			get job
+// @Security 		ApiKeyAuth
+// @Description		status, progress and result of a background job
+// @Tags			job
+// @Accept			json
+// @Produce			json
+// @Param			jobId   path	int	true  "job id"
+// @Success			200		{object}	ResponseJob
+// @Router			/jobs/{jobId} [get]
+func (A *API) GetJob(w http.ResponseWriter, r *http.Request) {
+	jobId, err := parseID(r, "jobId")
+	if err != nil {
+		A.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	job, err := A.jobService.GetJob(r.Context(), jobId)
+	if err != nil {
+		A.respondError(w, err)
+		return
+	}
+
+	A.responder.OutputJSON(w, ResponseJob{
+		Success: true,
+		Data: JobData{
+			Job: job,
+		},
+	})
+}
+
+// @Summary			reindex pet search
+// @Security 		ApiKeyAuth
+// @Description		rebuild the search documents of all pets in a background job
+// @Tags			pet
+// @Accept			json
+// @Produce			json
+// @Success			202		{object}	ResponseJob
+// @Router			/pet/search/reindex [post]
+func (A *API) ReindexPetSearch(w http.ResponseWriter, r *http.Request) {
+	job, err := A.jobService.Enqueue(r.Context(), pService.ReindexJob, struct{}{}, jService.DefaultMaxAttempts)
+	if err != nil {
+		A.respondError(w, err)
+		return
+	}
+	A.acceptJob(w, job)
+}
+
+// acceptJob answers 202 with the location of the queued job.
+func (A *API) acceptJob(w http.ResponseWriter, job entity.Job) {
+	w.Header().Set("Location", fmt.Sprintf("/jobs/%d", job.Id))
+	A.responder.OutputAccepted(w, ResponseJob{
+		Success: true,
+		Data: JobData{
+			Message: fmt.Sprintf("job %d queued", job.Id),
+			Job:     job,
+		},
+	})
+}
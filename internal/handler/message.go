@@ -1,6 +1,9 @@
 package handler
 
-import "swagger_petstore/petstore"
+import (
+	"swagger_petstore/entity"
+	"swagger_petstore/petstore"
+)
 
 type LoginData struct {
 	Message string `json:"message"`
@@ -81,6 +84,106 @@ type InventoryData struct {
 	Inventory map[string]int32 `json:"inventory"`
 }
 
+type ResponseInventoryHistory struct {
+	Success bool                 `json:"success"`
+	Data    InventoryHistoryData `json:"data"`
+}
+
+type InventoryHistoryData struct {
+	Message string                  `json:"message"`
+	History []entity.InventoryPoint `json:"history"`
+}
+
+type ResponseCategory struct {
+	Success bool         `json:"success"`
+	Data    CategoryData `json:"data"`
+}
+
+type CategoryData struct {
+	Message  string            `json:"message"`
+	Category petstore.Category `json:"category"`
+}
+
+type ResponseCategories struct {
+	Success bool           `json:"success"`
+	Data    CategoriesData `json:"data"`
+}
+
+type CategoriesData struct {
+	Message    string              `json:"message"`
+	Categories []petstore.Category `json:"categories"`
+}
+
+type ResponseTags struct {
+	Success bool     `json:"success"`
+	Data    TagsData `json:"data"`
+}
+
+type TagsData struct {
+	Message string            `json:"message"`
+	Tags    []entity.TagUsage `json:"tags"`
+}
+
+type ResponseJob struct {
+	Success bool    `json:"success"`
+	Data    JobData `json:"data"`
+}
+
+type JobData struct {
+	Message string     `json:"message"`
+	Job     entity.Job `json:"job"`
+}
+
+type ResponseImport struct {
+	Success bool       `json:"success"`
+	Data    ImportData `json:"data"`
+}
+
+type ImportData struct {
+	Message string              `json:"message"`
+	Report  entity.ImportReport `json:"report"`
+}
+
+type ResponseAudit struct {
+	Success bool      `json:"success"`
+	Data    AuditData `json:"data"`
+}
+
+type AuditData struct {
+	Message string              `json:"message"`
+	Entries []entity.AuditEntry `json:"entries"`
+}
+
+type ResponseWebhook struct {
+	Success bool        `json:"success"`
+	Data    WebhookData `json:"data"`
+}
+
+type WebhookData struct {
+	Message string                     `json:"message"`
+	Webhook entity.WebhookSubscription `json:"webhook"`
+}
+
+type ResponseWebhooks struct {
+	Success bool         `json:"success"`
+	Data    WebhooksData `json:"data"`
+}
+
+type WebhooksData struct {
+	Message  string                       `json:"message"`
+	Webhooks []entity.WebhookSubscription `json:"webhooks"`
+}
+
+type ResponseDeliveries struct {
+	Success bool           `json:"success"`
+	Data    DeliveriesData `json:"data"`
+}
+
+type DeliveriesData struct {
+	Message    string                   `json:"message"`
+	Deliveries []entity.WebhookDelivery `json:"deliveries"`
+}
+
 type Data struct {
 	Message string `json:"message"`
 }
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"swagger_petstore/petstore"
+)
+
+// @Summary			list tags
+// @Security 		ApiKeyAuth
+// @Description		list tags with the number of pets using them
+// @Tags			tag
+// @Accept			json
+// @Produce			json
+// @Success			200		{object}	ResponseTags
+// @Router			/tag [get]
+func (A *API) GetTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := A.tagService.GetTags(r.Context())
+	if err != nil {
+		A.respondError(w, err)
+		return
+	}
+
+	A.responder.OutputJSON(w, ResponseTags{
+		Success: true,
+		Data: TagsData{
+			Tags: tags,
+		},
+	})
+}
+
+// @Summary			rename tag
+// @Security 		ApiKeyAuth
+// @Description		rename tag
+// @Tags			tag
+// @Accept			json
+// @Produce			json
+// @Param			tagId   path	int				true  "tag id"
+// @Param			tag     body	petstore.Tag	true  "new tag name"
+// @Success			200		{object}	ResponseData
+// @Router			/tag/{tagId} [put]
+func (A *API) RenameTag(w http.ResponseWriter, r *http.Request) {
+	tagId, err := parseID(r, "tagId")
+	if err != nil {
+		A.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	var tag petstore.Tag
+	if err := json.NewDecoder(r.Body).Decode(&tag); err != nil {
+		A.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	err = A.tagService.RenameTag(r.Context(), tagId, tag)
+	if err != nil {
+		A.respondError(w, err)
+		return
+	}
+
+	A.responder.OutputJSON(w, ResponseData{
+		Success: true,
+		Data: Data{
+			Message: fmt.Sprintf("tag number %d has been renamed", tagId),
+		},
+	})
+}
+
+// @Summary			merge tags
+// @Security 		ApiKeyAuth
+// @Description		move all pets of the tag to another tag and delete it
+// @Tags			tag
+// @Accept			json
+// @Produce			json
+// @Param			tagId   path	int	true  "tag id to merge"
+// @Param			into    query	int	true  "tag id to merge into"
+// @Success			200		{object}	ResponseData
+// @Router			/tag/{tagId}/merge [post]
+func (A *API) MergeTags(w http.ResponseWriter, r *http.Request) {
+	tagId, err := parseID(r, "tagId")
+	if err != nil {
+		A.responder.ErrorBadRequest(w, err)
+		return
+	}
+	into, err := strconv.ParseInt(r.URL.Query().Get("into"), 10, 64)
+	if err != nil {
+		A.responder.ErrorBadRequest(w, fmt.Errorf("invalid into: %w", err))
+		return
+	}
+
+	err = A.tagService.MergeTags(r.Context(), tagId, into)
+	if err != nil {
+		A.respondError(w, err)
+		return
+	}
+
+	A.responder.OutputJSON(w, ResponseData{
+		Success: true,
+		Data: Data{
+			Message: fmt.Sprintf("tag number %d has been merged into %d", tagId, into),
+		},
+	})
+}
+
+// @Summary			delete tag
+// @Security 		ApiKeyAuth
+// @Description		delete a tag that is not used by any pet
+// @Tags			tag
+// @Accept			json
+// @Produce			json
+// @Param			tagId   path	int	true  "tag id"
+// @Success			200		{object}	ResponseData
+// @Router			/tag/{tagId} [delete]
+func (A *API) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	tagId, err := parseID(r, "tagId")
+	if err != nil {
+		A.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	err = A.tagService.DeleteTag(r.Context(), tagId)
+	if err != nil {
+		A.respondError(w, err)
+		return
+	}
+
+	A.responder.OutputJSON(w, ResponseData{
+		Success: true,
+		Data: Data{
+			Message: fmt.Sprintf("tag number %d has been deleted", tagId),
+		},
+	})
+}
+
+// @Summary			delete unused tags
+// @Security 		ApiKeyAuth
+// @Description		delete all tags that are not used by any pet
+// @Tags			tag
+// @Accept			json
+// @Produce			json
+// @Success			200		{object}	ResponseData
+// @Router			/tag/unused [delete]
+func (A *API) DeleteUnusedTags(w http.ResponseWriter, r *http.Request) {
+	deleted, err := A.tagService.DeleteUnusedTags(r.Context())
+	if err != nil {
+		A.respondError(w, err)
+		return
+	}
+
+	A.responder.OutputJSON(w, ResponseData{
+		Success: true,
+		Data: Data{
+			Message: fmt.Sprintf("deleted %d unused tags", deleted),
+		},
+	})
+}
@@ -0,0 +1,237 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"swagger_petstore/entity"
+)
+
+// @Summary			create webhook
+// @Security 		ApiKeyAuth
+// @Description		subscribe a URL to domain events, an empty eventTypes list subscribes to all events. Deliveries are signed with HMAC-SHA256 of "<X-Petstore-Timestamp>.<body>" in the X-Petstore-Signature header; the secret is generated when omitted and returned only in this response
+// @Tags			webhook
+// @Accept			json
+// @Produce			json
+// @Param			webhook   body	entity.WebhookSubscription	true  "webhook subscription"
+// @Success			200		{object}	ResponseWebhook
+// @Router			/webhooks [post]
+func (A *API) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	subscription := entity.WebhookSubscription{Active: true}
+	if err := json.NewDecoder(r.Body).Decode(&subscription); err != nil {
+		A.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	subscription, err := A.webhookService.CreateSubscription(r.Context(), subscription)
+	if err != nil {
+		A.respondError(w, err)
+		return
+	}
+
+	A.responder.OutputJSON(w, ResponseWebhook{
+		Success: true,
+		Data: WebhookData{
+			Message: fmt.Sprintf("added a new webhook %d", subscription.Id),
+			Webhook: subscription,
+		},
+	})
+}
+
+// @Summary			list webhooks
+// @Security 		ApiKeyAuth
+// @Description		list webhook subscriptions without their secrets
+// @Tags			webhook
+// @Accept			json
+// @Produce			json
+// @Success			200		{object}	ResponseWebhooks
+// @Router			/webhooks [get]
+func (A *API) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	subscriptions, err := A.webhookService.GetSubscriptions(r.Context())
+	if err != nil {
+		A.respondError(w, err)
+		return
+	}
+
+	A.responder.OutputJSON(w, ResponseWebhooks{
+		Success: true,
+		Data: WebhooksData{
+			Webhooks: subscriptions,
+		},
+	})
+}
+
+// @Summary			get webhook
+// @Security 		ApiKeyAuth
+// @Description		get webhook subscription without its secret
+// @Tags			webhook
+// @Accept			json
+// @Produce			json
+// @Param			webhookId   path	int	true  "webhook id"
+// @Success			200		{object}	ResponseWebhook
+// @Router			/webhooks/{webhookId} [get]
+func (A *API) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookId, err := parseID(r, "webhookId")
+	if err != nil {
+		A.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	subscription, err := A.webhookService.GetSubscription(r.Context(), webhookId)
+	if err != nil {
+		A.respondError(w, err)
+		return
+	}
+
+	A.responder.OutputJSON(w, ResponseWebhook{
+		Success: true,
+		Data: WebhookData{
+			Webhook: subscription,
+		},
+	})
+}
+
+// @Summary			update webhook
+// @Security 		ApiKeyAuth
+// @Description		replace webhook subscription, an omitted secret keeps the current one
+// @Tags			webhook
+// @Accept			json
+// @Produce			json
+// @Param			webhookId   path	int							true  "webhook id"
+// @Param			webhook		body	entity.WebhookSubscription	true  "webhook subscription"
+// @Success			200		{object}	ResponseData
+// @Router			/webhooks/{webhookId} [put]
+func (A *API) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookId, err := parseID(r, "webhookId")
+	if err != nil {
+		A.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	subscription := entity.WebhookSubscription{Active: true}
+	if err := json.NewDecoder(r.Body).Decode(&subscription); err != nil {
+		A.responder.ErrorBadRequest(w, err)
+		return
+	}
+	subscription.Id = webhookId
+
+	if err := A.webhookService.UpdateSubscription(r.Context(), subscription); err != nil {
+		A.respondError(w, err)
+		return
+	}
+
+	A.responder.OutputJSON(w, ResponseData{
+		Success: true,
+		Data: Data{
+			Message: fmt.Sprintf("webhook number %d has been updated", webhookId),
+		},
+	})
+}
+
+// @Summary			delete webhook
+// @Security 		ApiKeyAuth
+// @Description		delete webhook subscription with its delivery log
+// @Tags			webhook
+// @Accept			json
+// @Produce			json
+// @Param			webhookId   path	int	true  "webhook id"
+// @Success			200		{object}	ResponseData
+// @Router			/webhooks/{webhookId} [delete]
+func (A *API) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookId, err := parseID(r, "webhookId")
+	if err != nil {
+		A.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	if err := A.webhookService.DeleteSubscription(r.Context(), webhookId); err != nil {
+		A.respondError(w, err)
+		return
+	}
+
+	A.responder.OutputJSON(w, ResponseData{
+		Success: true,
+		Data: Data{
+			Message: fmt.Sprintf("webhook number %d has been deleted", webhookId),
+		},
+	})
+}
+
+// @Summary			webhook deliveries
+// @Security 		ApiKeyAuth
+// @Description		delivery log of the webhook, newest first
+// @Tags			webhook
+// @Accept			json
+// @Produce			json
+// @Param			webhookId   path	int	true  "webhook id"
+// @Param			limit		query	int	false	"page size, 50 by default"
+// @Param			offset		query	int	false	"deliveries to skip"
+// @Success			200		{object}	ResponseDeliveries
+// @Router			/webhooks/{webhookId}/deliveries [get]
+func (A *API) GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	webhookId, err := parseID(r, "webhookId")
+	if err != nil {
+		A.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	query := r.URL.Query()
+	limit, err := parseInt(query.Get("limit"))
+	if err != nil {
+		A.responder.ErrorBadRequest(w, fmt.Errorf("invalid limit: %w", err))
+		return
+	}
+	offset, err := parseInt(query.Get("offset"))
+	if err != nil {
+		A.responder.ErrorBadRequest(w, fmt.Errorf("invalid offset: %w", err))
+		return
+	}
+
+	deliveries, err := A.webhookService.GetDeliveries(r.Context(), webhookId, limit, offset)
+	if err != nil {
+		A.respondError(w, err)
+		return
+	}
+
+	A.responder.OutputJSON(w, ResponseDeliveries{
+		Success: true,
+		Data: DeliveriesData{
+			Deliveries: deliveries,
+		},
+	})
+}
+
+// @Summary			redeliver webhook
+// @Security 		ApiKeyAuth
+// @Description		send the delivery again with a fresh set of attempts
+// @Tags			webhook
+// @Accept			json
+// @Produce			json
+// @Param			webhookId   path	int	true  "webhook id"
+// @Param			deliveryId  path	int	true  "delivery id"
+// @Success			202		{object}	ResponseData
+// @Router			/webhooks/{webhookId}/deliveries/{deliveryId}/redeliver [post]
+func (A *API) RedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookId, err := parseID(r, "webhookId")
+	if err != nil {
+		A.responder.ErrorBadRequest(w, err)
+		return
+	}
+	deliveryId, err := parseID(r, "deliveryId")
+	if err != nil {
+		A.responder.ErrorBadRequest(w, err)
+		return
+	}
+
+	if err := A.webhookService.Redeliver(r.Context(), webhookId, deliveryId); err != nil {
+		A.respondError(w, err)
+		return
+	}
+
+	A.responder.OutputAccepted(w, ResponseData{
+		Success: true,
+		Data: Data{
+			Message: fmt.Sprintf("delivery %d queued", deliveryId),
+		},
+	})
+}
@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"swagger_petstore/entity"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type JobsRepository interface {
+	Enqueue(ctx context.Context, jobType string, payload []byte, maxAttempts int) (entity.Job, error)
+	Claim(ctx context.Context) (entity.Job, error)
+	SetProgress(ctx context.Context, jobId int64, progress int) error
+	Heartbeat(ctx context.Context, jobId int64) error
+	Complete(ctx context.Context, jobId int64, result []byte) error
+	Retry(ctx context.Context, jobId int64, reason string, runAt time.Time) error
+	Bury(ctx context.Context, jobId int64, reason string) error
+	Release(ctx context.Context, jobId int64) error
+	RequeueStale(ctx context.Context, lockedBefore time.Time) (int64, error)
+	GetJob(ctx context.Context, jobId int64) (entity.Job, error)
+}
+type Repository struct {
+	db *sqlx.DB
+}
+
+func NewJobRepository(db *sqlx.DB) JobsRepository {
+	return &Repository{db: db}
+}
+
+// NULL can't be scanned into json.RawMessage or string, so optional columns are read back with defaults.
+const jobColumns = `id, type, payload, status, attempts, max_attempts, progress,
+	COALESCE(result, 'null') AS result, COALESCE(last_error, '') AS last_error,
+	run_at, created_at, updated_at, finished_at`
+
+func (r *Repository) Enqueue(ctx context.Context, jobType string, payload []byte, maxAttempts int) (entity.Job, error) {
+	var job entity.Job
+	err := r.db.GetContext(ctx, &job, `
+		INSERT INTO jobs (type, payload, max_attempts)
+		VALUES ($1, $2::jsonb, $3)
+		RETURNING `+jobColumns,
+		jobType, string(payload), maxAttempts,
+	)
+	if err != nil {
+		return entity.Job{}, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	return job, nil
+}
+
+// Claim locks the oldest due job for this worker. Jobs locked by other workers are skipped,
+// so any number of workers can claim concurrently. It returns entity.ErrNotFound when the
+// queue is empty.
+func (r *Repository) Claim(ctx context.Context) (entity.Job, error) {
+	var job entity.Job
+	err := r.db.GetContext(ctx, &job, `
+		UPDATE jobs
+		SET status = 'running', attempts = attempts + 1, locked_at = NOW(), updated_at = NOW()
+		WHERE id = (
+			SELECT id
+			FROM jobs
+			WHERE status = 'queued' AND run_at <= NOW()
+			ORDER BY run_at, id
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING `+jobColumns)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.Job{}, entity.ErrNotFound
+	}
+	if err != nil {
+		return entity.Job{}, fmt.Errorf("failed to claim job: %w", err)
+	}
+	return job, nil
+}
+
+func (r *Repository) SetProgress(ctx context.Context, jobId int64, progress int) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE jobs SET progress = $2, updated_at = NOW()
+		WHERE id = $1 AND status = 'running'`,
+		jobId, progress,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update job progress: %w", err)
+	}
+	return nil
+}
+
+// Heartbeat renews the lock of a running job so it isn't taken for abandoned.
+func (r *Repository) Heartbeat(ctx context.Context, jobId int64) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE jobs SET locked_at = NOW()
+		WHERE id = $1 AND status = 'running'`,
+		jobId,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to renew job lock: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) Complete(ctx context.Context, jobId int64, result []byte) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE jobs
+		SET status = 'succeeded', progress = 100, result = $2::jsonb, last_error = NULL,
+			locked_at = NULL, updated_at = NOW(), finished_at = NOW()
+		WHERE id = $1`,
+		jobId, jsonb(result),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to complete job: %w", err)
+	}
+	return nil
+}
+
+// Retry puts the job back in the queue to run again at runAt.
+func (r *Repository) Retry(ctx context.Context, jobId int64, reason string, runAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE jobs
+		SET status = 'queued', last_error = $2, run_at = $3, locked_at = NULL, updated_at = NOW()
+		WHERE id = $1`,
+		jobId, reason, runAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to retry job: %w", err)
+	}
+	return nil
+}
+
+// Bury moves the job to the dead letters, it is kept for inspection but never run again.
+func (r *Repository) Bury(ctx context.Context, jobId int64, reason string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE jobs
+		SET status = 'dead', last_error = $2, locked_at = NULL, updated_at = NOW(), finished_at = NOW()
+		WHERE id = $1`,
+		jobId, reason,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to bury job: %w", err)
+	}
+	return nil
+}
+
+// Release puts a job interrupted by a shutdown back in the queue without using up an attempt.
+func (r *Repository) Release(ctx context.Context, jobId int64) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE jobs
+		SET status = 'queued', attempts = attempts - 1, run_at = NOW(), locked_at = NULL, updated_at = NOW()
+		WHERE id = $1`,
+		jobId,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to release job: %w", err)
+	}
+	return nil
+}
+
+// RequeueStale returns jobs whose worker stopped before finishing them to the queue.
+func (r *Repository) RequeueStale(ctx context.Context, lockedBefore time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE jobs
+		SET status = CASE WHEN attempts < max_attempts THEN 'queued' ELSE 'dead' END,
+			last_error = 'worker stopped', locked_at = NULL, updated_at = NOW(),
+			finished_at = CASE WHEN attempts < max_attempts THEN NULL ELSE NOW() END
+		WHERE status = 'running' AND locked_at < $1`,
+		lockedBefore,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue stale jobs: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+func (r *Repository) GetJob(ctx context.Context, jobId int64) (entity.Job, error) {
+	var job entity.Job
+	err := r.db.GetContext(ctx, &job, `SELECT `+jobColumns+` FROM jobs WHERE id = $1`, jobId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.Job{}, fmt.Errorf("job %d: %w", jobId, entity.ErrNotFound)
+	}
+	if err != nil {
+		return entity.Job{}, fmt.Errorf("failed to get job: %w", err)
+	}
+	return job, nil
+}
+
+// jsonb passes raw JSON as text so it can be cast to jsonb, keeping empty values NULL.
+func jsonb(raw []byte) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"swagger_petstore/backoff"
+	"swagger_petstore/entity"
+	"swagger_petstore/internal/job/repository"
+	"swagger_petstore/logging"
+	"swagger_petstore/tracing"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultMaxAttempts = 5
+
+	pollInterval = time.Second
+	// heartbeatInterval must stay well below the timeout passed to RequeueStale.
+	heartbeatInterval = time.Minute
+	retryBackoff      = 10 * time.Second
+	maxBackoff        = 10 * time.Minute
+)
+
+// Handler runs one job. It can report progress in percent and returns the result stored with the job.
+type Handler func(ctx context.Context, payload json.RawMessage, progress func(percent int)) (interface{}, error)
+
+type Servicer interface {
+	Register(jobType string, handler Handler)
+	Enqueue(ctx context.Context, jobType string, payload interface{}, maxAttempts int) (entity.Job, error)
+	GetJob(ctx context.Context, jobId int64) (entity.Job, error)
+	Work(ctx context.Context)
+	RequeueStale(ctx context.Context, timeout time.Duration) (int64, error)
+}
+type JobService struct {
+	repository repository.JobsRepository
+	logger     *zap.Logger
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+}
+
+func NewJobService(repository repository.JobsRepository, logger *zap.Logger) *JobService {
+	return &JobService{repository: repository, logger: logger, handlers: map[string]Handler{}}
+}
+
+// Register sets the handler of a job type, it must be called before the workers start.
+func (s *JobService) Register(jobType string, handler Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[jobType] = handler
+}
+
+func (s *JobService) Enqueue(ctx context.Context, jobType string, payload interface{}, maxAttempts int) (entity.Job, error) {
+	if s.handler(jobType) == nil {
+		return entity.Job{}, fmt.Errorf("%w: unknown job type %q", entity.ErrInvalid, jobType)
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return entity.Job{}, err
+	}
+	return s.repository.Enqueue(ctx, jobType, data, maxAttempts)
+}
+
+func (s *JobService) GetJob(ctx context.Context, jobId int64) (entity.Job, error) {
+	if jobId <= 0 {
+		return entity.Job{}, fmt.Errorf("%w: jobId must be a positive number", entity.ErrInvalid)
+	}
+	return s.repository.GetJob(ctx, jobId)
+}
+
+// Work claims and runs jobs until ctx is cancelled. A job interrupted by the cancellation
+// goes back to the queue, unless it is allowed a single attempt: such a job isn't safe to run
+// again, so it is moved to the dead letters.
+func (s *JobService) Work(ctx context.Context) {
+	for {
+		job, err := s.repository.Claim(ctx)
+		if err == nil {
+			s.run(ctx, job)
+			continue
+		}
+		if !errors.Is(err, entity.ErrNotFound) && ctx.Err() == nil {
+			s.logger.Error("job: failed to claim", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// RequeueStale returns jobs locked longer than timeout to the queue, their worker is gone.
+func (s *JobService) RequeueStale(ctx context.Context, timeout time.Duration) (int64, error) {
+	return s.repository.RequeueStale(ctx, time.Now().Add(-timeout))
+}
+
+func (s *JobService) run(ctx context.Context, job entity.Job) {
+	logger := s.logger.With(zap.Int64("job_id", job.Id), zap.String("job_type", job.Type), zap.Int("attempt", job.Attempts))
+	ctx = logging.WithLogger(ctx, logger)
+	// The job outcome is stored even when the worker is stopping.
+	store := context.WithoutCancel(ctx)
+
+	handler := s.handler(job.Type)
+	if handler == nil {
+		s.fail(store, logger, job, fmt.Errorf("no handler for job type %q", job.Type), false)
+		return
+	}
+
+	progress := func(percent int) {
+		if err := s.repository.SetProgress(store, job.Id, min(max(percent, 0), 100)); err != nil {
+			logger.Warn("job: failed to report progress", zap.Error(err))
+		}
+	}
+
+	ctx, span := tracing.Start(ctx, "job "+job.Type)
+	stop := s.heartbeat(store, logger, job.Id)
+	result, err := call(ctx, handler, job.Payload, progress)
+	stop()
+	tracing.End(span, err)
+	if err != nil && ctx.Err() != nil && job.MaxAttempts <= 1 {
+		s.fail(store, logger, job, fmt.Errorf("interrupted by shutdown: %w", err), false)
+		return
+	}
+	if err != nil && ctx.Err() != nil {
+		logger.Info("job: interrupted by shutdown, requeued", zap.Error(err))
+		if err := s.repository.Release(store, job.Id); err != nil {
+			logger.Error("job: failed to release", zap.Error(err))
+		}
+		return
+	}
+	if err != nil {
+		s.fail(store, logger, job, err, true)
+		return
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		s.fail(store, logger, job, fmt.Errorf("failed to encode result: %w", err), false)
+		return
+	}
+	if err := s.repository.Complete(store, job.Id, data); err != nil {
+		logger.Error("job: failed to complete", zap.Error(err))
+		return
+	}
+	logger.Info("job: succeeded")
+}
+
+// heartbeat renews the job lock until the returned function is called.
+func (s *JobService) heartbeat(ctx context.Context, logger *zap.Logger, jobId int64) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(heartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := s.repository.Heartbeat(ctx, jobId); err != nil {
+					logger.Warn("job: failed to renew lock", zap.Error(err))
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// call runs the handler and turns a panic into an error so one bad job can't stop the worker.
+func call(ctx context.Context, handler Handler, payload json.RawMessage, progress func(int)) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return handler(ctx, payload, progress)
+}
+
+// fail schedules another attempt with exponential backoff, or buries the job once it is out
+// of attempts or retrying can't help.
+func (s *JobService) fail(ctx context.Context, logger *zap.Logger, job entity.Job, cause error, retry bool) {
+	if retry && job.Attempts < job.MaxAttempts {
+		runAt := time.Now().Add(backoff.Exponential(retryBackoff, maxBackoff, job.Attempts))
+		logger.Warn("job: failed, retrying", zap.Error(cause), zap.Time("run_at", runAt))
+		if err := s.repository.Retry(ctx, job.Id, cause.Error(), runAt); err != nil {
+			logger.Error("job: failed to retry", zap.Error(err))
+		}
+		return
+	}
+
+	logger.Error("job: failed, moved to dead letters", zap.Error(cause))
+	if err := s.repository.Bury(ctx, job.Id, cause.Error()); err != nil {
+		logger.Error("job: failed to bury", zap.Error(err))
+	}
+}
+
+func (s *JobService) handler(jobType string) Handler {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.handlers[jobType]
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"swagger_petstore/entity"
+	"swagger_petstore/internal/job/repository"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// fakeRepository records how the outcome of a job is stored.
+type fakeRepository struct {
+	repository.JobsRepository
+	released, buried []int64
+}
+
+func (r *fakeRepository) Heartbeat(context.Context, int64) error { return nil }
+
+func (r *fakeRepository) Release(_ context.Context, jobId int64) error {
+	r.released = append(r.released, jobId)
+	return nil
+}
+
+func (r *fakeRepository) Bury(_ context.Context, jobId int64, _ string) error {
+	r.buried = append(r.buried, jobId)
+	return nil
+}
+
+func TestRunInterruptedByShutdown(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxAttempts  int
+		wantReleased bool
+	}{
+		{"retryable", DefaultMaxAttempts, true},
+		{"single attempt", 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepository{}
+			s := NewJobService(repo, zap.NewNop())
+			ctx, cancel := context.WithCancel(context.Background())
+			s.Register("test", func(ctx context.Context, _ json.RawMessage, _ func(int)) (interface{}, error) {
+				cancel()
+				return nil, ctx.Err()
+			})
+
+			s.run(ctx, entity.Job{Id: 7, Type: "test", Attempts: 1, MaxAttempts: tt.maxAttempts})
+
+			if released := len(repo.released) == 1; released != tt.wantReleased {
+				t.Errorf("released %v, want %v", repo.released, tt.wantReleased)
+			}
+			if buried := len(repo.buried) == 1; buried == tt.wantReleased {
+				t.Errorf("buried %v, want %v", repo.buried, !tt.wantReleased)
+			}
+		})
+	}
+}
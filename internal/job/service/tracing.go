@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"swagger_petstore/entity"
+	"swagger_petstore/tracing"
+	"time"
+)
+
+// tracedService starts a span around the methods of the service that can fail.
+type tracedService struct {
+	Servicer
+}
+
+// Traced wraps the service so that its calls are traced.
+func Traced(service Servicer) Servicer {
+	return tracedService{Servicer: service}
+}
+
+func (s tracedService) Enqueue(ctx context.Context, jobType string, payload interface{}, maxAttempts int) (_ entity.Job, err error) {
+	ctx, span := tracing.Start(ctx, "JobService.Enqueue")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.Enqueue(ctx, jobType, payload, maxAttempts)
+}
+
+func (s tracedService) GetJob(ctx context.Context, jobId int64) (_ entity.Job, err error) {
+	ctx, span := tracing.Start(ctx, "JobService.GetJob")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.GetJob(ctx, jobId)
+}
+
+func (s tracedService) RequeueStale(ctx context.Context, timeout time.Duration) (_ int64, err error) {
+	ctx, span := tracing.Start(ctx, "JobService.RequeueStale")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.RequeueStale(ctx, timeout)
+}
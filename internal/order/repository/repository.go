@@ -2,67 +2,232 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"swagger_petstore/entity"
+	outbox "swagger_petstore/internal/outbox/repository"
 	"swagger_petstore/petstore"
+	"swagger_petstore/postgres"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
 
 type OrderRepository interface {
 	GetInventory(ctx context.Context) (map[string]int32, error)
-	PlaceOrder(ctx context.Context, order petstore.Order) error
-	DeleteOrder(ctx context.Context, orderId int64) error
+	PlaceOrder(ctx context.Context, order petstore.Order, username string) error
+	DeleteOrder(ctx context.Context, orderId int64, version int64) error
 	GetOrderById(ctx context.Context, orderId int64) (petstore.Order, error)
+	GetVersionedOrder(ctx context.Context, orderId int64) (petstore.Order, int64, error)
+	GetOrderOwner(ctx context.Context, orderId int64) (string, error)
+	SnapshotInventory(ctx context.Context) error
+	GetInventoryHistory(ctx context.Context, from, to time.Time, interval string) ([]entity.InventoryPoint, error)
+	ExportOrders(ctx context.Context, filter entity.OrderFilter, each func(order entity.OrderRecord) error) error
 }
 type Repository struct {
 	db *sqlx.DB
 }
 
+type versionedOrder struct {
+	petstore.Order
+	Version int64 `db:"version"`
+}
+
 func NewOrderRepository(db *sqlx.DB) OrderRepository {
 	return &Repository{db: db}
 }
 
+// GetInventory counts the pets by status, those without a status are counted under "".
 func (r *Repository) GetInventory(ctx context.Context) (map[string]int32, error) {
-	var pets []petstore.Pet
-	res := make(map[string]int32)
-
-	query := `SELECT status
-			 FROM pets`
-
-	err := r.db.Select(&pets, query)
+	var counts []struct {
+		Status string `db:"status"`
+		Pets   int32  `db:"pets"`
+	}
+	err := r.db.SelectContext(ctx, &counts, `
+		SELECT COALESCE(status, '') AS status, COUNT(*) AS pets
+		FROM pets
+		WHERE deleted_at IS NULL
+		GROUP BY 1`)
 	if err != nil {
-		return res, fmt.Errorf("failed to get pets: %w", err)
+		return nil, fmt.Errorf("failed to count pets: %w", err)
 	}
 
-	for _, pet := range pets {
-		res[string(*pet.Status)]++
+	res := make(map[string]int32, len(counts))
+	for _, c := range counts {
+		res[c.Status] = c.Pets
 	}
-
 	return res, nil
 }
 
-func (r *Repository) PlaceOrder(ctx context.Context, order petstore.Order) error {
-	_, err := r.db.ExecContext(ctx, "INSERT INTO orders (id, complete, petId, quantity, shipDate, status) VALUES ($1, $2, $3, $4, $5, $6)",
-		order.Id, order.Complete, order.PetId, order.Quantity, order.ShipDate, order.Status)
+// PlaceOrder stores the order placed by username. It is owned by the id of the user, so it
+// stays with them across renames and doesn't pass to a later user of the same name.
+func (r *Repository) PlaceOrder(ctx context.Context, order petstore.Order, username string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var orderId int64
+	err = tx.QueryRowContext(ctx, `INSERT INTO orders (id, complete, petId, quantity, shipDate, status, user_id)
+			VALUES ($1, $2, $3, $4, $5, $6, (SELECT id FROM users WHERE username = $7 AND deleted_at IS NULL ORDER BY id LIMIT 1))
+			RETURNING id`,
+		order.Id, order.Complete, order.PetId, order.Quantity, order.ShipDate, order.Status, username).Scan(&orderId)
 	if err != nil {
 		return fmt.Errorf("failed to create order: %w", err)
 	}
-	return nil
+
+	order.Id = &orderId
+	if err := outbox.AddEvent(ctx, tx, entity.EventOrderPlaced, strconv.FormatInt(orderId, 10), order); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func (r *Repository) DeleteOrder(ctx context.Context, orderId int64) error {
-	_, err := r.db.Exec("DELETE FROM orders WHERE id=$1", orderId)
-	return err
+// DeleteOrder removes the order, a non-zero version must match the stored one.
+func (r *Repository) DeleteOrder(ctx context.Context, orderId int64, version int64) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var petId int64
+	err = tx.QueryRowContext(ctx, "DELETE FROM orders WHERE id=$1 AND ($2 = 0 OR version = $2) RETURNING petId", orderId, version).Scan(&petId)
+	if err == nil {
+		cancellation := entity.OrderCancellation{OrderId: orderId, PetId: petId}
+		if err := outbox.AddEvent(ctx, tx, entity.EventOrderCancelled, strconv.FormatInt(orderId, 10), cancellation); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to delete order: %w", err)
+	}
+
+	var current int64
+	err = r.db.GetContext(ctx, &current, "SELECT version FROM orders WHERE id = $1", orderId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("order %d: %w", orderId, entity.ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get order version: %w", err)
+	}
+	return fmt.Errorf("order version is %d, not %d: %w", current, version, entity.ErrPreconditionFailed)
 }
 
 func (r *Repository) GetOrderById(ctx context.Context, orderId int64) (petstore.Order, error) {
-	var order petstore.Order
-	query := `SELECT *
+	order, _, err := r.GetVersionedOrder(ctx, orderId)
+	return order, err
+}
+
+func (r *Repository) GetVersionedOrder(ctx context.Context, orderId int64) (petstore.Order, int64, error) {
+	var order versionedOrder
+	query := `SELECT id, complete, petId, quantity, shipDate, status, version
 			 FROM orders 
 			 WHERE id = $1`
 	err := r.db.GetContext(ctx, &order, query, orderId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return petstore.Order{}, 0, fmt.Errorf("order %d: %w", orderId, entity.ErrNotFound)
+	}
+	if err != nil {
+		return petstore.Order{}, 0, fmt.Errorf("failed to get order by ID: %w", err)
+	}
+	return order.Order, order.Version, nil
+}
+
+// GetOrderOwner returns the current username of the user who placed the order, or an empty
+// string for the orders placed before they had owners or whose owner is gone.
+func (r *Repository) GetOrderOwner(ctx context.Context, orderId int64) (string, error) {
+	var owner sql.NullString
+	err := r.db.GetContext(ctx, &owner, `
+		SELECT u.username FROM orders o
+		LEFT JOIN users u ON u.id = o.user_id AND u.deleted_at IS NULL
+		WHERE o.id = $1`, orderId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("order %d: %w", orderId, entity.ErrNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get order owner: %w", err)
+	}
+	return owner.String, nil
+}
+
+func (r *Repository) SnapshotInventory(ctx context.Context) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO inventory_snapshots (taken_at, status, category, count)
+		SELECT NOW(), COALESCE(p.status, ''), COALESCE(c.name, ''), COUNT(*)
+		FROM pets p
+		LEFT JOIN categories c ON p.category_id = c.id
+		WHERE p.deleted_at IS NULL
+		GROUP BY 2, 3`)
+	if err != nil {
+		return fmt.Errorf("failed to snapshot inventory: %w", err)
+	}
+	return nil
+}
+
+// GetInventoryHistory returns the latest snapshot of every interval bucket between from and to.
+func (r *Repository) GetInventoryHistory(ctx context.Context, from, to time.Time, interval string) ([]entity.InventoryPoint, error) {
+	points := []entity.InventoryPoint{}
+	query := `
+		WITH buckets AS (
+			SELECT date_trunc($3, taken_at) AS bucket, MAX(taken_at) AS taken_at
+			FROM inventory_snapshots
+			WHERE taken_at >= $1 AND taken_at < $2
+			GROUP BY 1
+		)
+		SELECT b.bucket, s.status, s.category, s.count
+		FROM buckets b
+		JOIN inventory_snapshots s ON s.taken_at = b.taken_at
+		ORDER BY b.bucket, s.status, s.category`
+	err := r.db.SelectContext(ctx, &points, query, from, to, interval)
 	if err != nil {
-		return petstore.Order{}, fmt.Errorf("failed to get order by ID: %w", err)
+		return nil, fmt.Errorf("failed to get inventory history: %w", err)
 	}
-	return order, nil
+	return points, nil
+}
+
+// ExportOrders streams the orders matching the filter, ordered by id.
+func (r *Repository) ExportOrders(ctx context.Context, filter entity.OrderFilter, each func(order entity.OrderRecord) error) error {
+	var conditions []string
+	var args []interface{}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if filter.PetId != 0 {
+		args = append(args, filter.PetId)
+		conditions = append(conditions, fmt.Sprintf("petId = $%d", len(args)))
+	}
+	if !filter.From.IsZero() {
+		args = append(args, filter.From)
+		conditions = append(conditions, fmt.Sprintf("shipDate >= $%d", len(args)))
+	}
+	if !filter.To.IsZero() {
+		args = append(args, filter.To)
+		conditions = append(conditions, fmt.Sprintf("shipDate < $%d", len(args)))
+	}
+
+	query := `SELECT id, petId, quantity, shipDate, COALESCE(status, '') AS status, COALESCE(complete, FALSE) AS complete
+			 FROM orders`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY id"
+
+	err := postgres.EachRow(ctx, r.db, query, args, func(rows *sqlx.Rows) error {
+		var order entity.OrderRecord
+		if err := rows.StructScan(&order); err != nil {
+			return fmt.Errorf("failed to scan order row: %w", err)
+		}
+		return each(order)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export orders: %w", err)
+	}
+	return nil
 }
@@ -3,63 +3,167 @@ package service
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"swagger_petstore/authz"
+	"swagger_petstore/entity"
+	auditService "swagger_petstore/internal/audit/service"
 	"swagger_petstore/internal/order/repository"
 	petRepository "swagger_petstore/internal/pet/repository"
 
 	"swagger_petstore/petstore"
+	"time"
 )
 
+var inventoryIntervals = map[string]bool{
+	"hour":  true,
+	"day":   true,
+	"week":  true,
+	"month": true,
+}
+
 type Servicer interface {
 	GetInventory(ctx context.Context) (map[string]int32, error)
 	PlaceOrder(ctx context.Context, order petstore.Order) error
-	DeleteOrder(ctx context.Context, orderId int64) error
+	DeleteOrder(ctx context.Context, orderId int64, version int64) error
 	GetOrderById(ctx context.Context, orderId int64) (petstore.Order, error)
+	GetVersionedOrder(ctx context.Context, orderId int64) (petstore.Order, int64, error)
+	SnapshotInventory(ctx context.Context) error
+	GetInventoryHistory(ctx context.Context, from, to time.Time, interval string) ([]entity.InventoryPoint, error)
+	ExportOrders(ctx context.Context, filter entity.OrderFilter, each func(order entity.OrderRecord) error) error
 }
 type OrderService struct {
 	pRepository petRepository.PetsRepository
 	repository  repository.OrderRepository
+	audit       auditService.Recorder
 }
 
-func NewService(pRepository petRepository.PetsRepository, repository repository.OrderRepository) *OrderService {
-	return &OrderService{pRepository: pRepository, repository: repository}
+func NewService(pRepository petRepository.PetsRepository, repository repository.OrderRepository, audit auditService.Recorder) *OrderService {
+	return &OrderService{pRepository: pRepository, repository: repository, audit: audit}
 }
 
 func (s *OrderService) GetInventory(ctx context.Context) (map[string]int32, error) {
 	return s.repository.GetInventory(ctx)
 }
 
+// PlaceOrder places the order on behalf of the caller, who owns it.
 func (s *OrderService) PlaceOrder(ctx context.Context, order petstore.Order) error {
+	if order.PetId == nil {
+		return fmt.Errorf("%w: petId required", entity.ErrInvalid)
+	}
 	pet, err := s.pRepository.GetPetById(ctx, *order.PetId)
 	if err != nil {
-		return fmt.Errorf("pet not found")
+		return err
 	}
-	if *pet.Status == petstore.PetStatusSold {
-		return fmt.Errorf("pet sold out")
+	if pet.Status != nil && *pet.Status == petstore.PetStatusSold {
+		return fmt.Errorf("pet %d sold out: %w", *order.PetId, entity.ErrConflict)
 	}
 
-	err = s.repository.PlaceOrder(ctx, order)
+	principal, _ := authz.FromContext(ctx)
+	err = s.repository.PlaceOrder(ctx, order, principal.Username)
 	if err != nil {
 		return err
 	}
+	s.audit.Record(ctx, auditService.EntityOrder, orderKey(order.Id), auditService.ActionCreate, nil, order)
+
 	sold := string(petstore.PetStatusSold)
 	petStatus := petstore.UpdatePetWithFormParams{
 		Name:   &pet.Name,
 		Status: &sold,
 	}
-	s.pRepository.UpdatePetWithForm(ctx, *pet.Id, petStatus)
+	if err := s.pRepository.UpdatePetWithForm(ctx, *pet.Id, petStatus); err == nil {
+		after := pet
+		status := petstore.PetStatusSold
+		after.Status = &status
+		s.audit.Record(ctx, auditService.EntityPet, strconv.FormatInt(*pet.Id, 10), auditService.ActionUpdate, pet, after)
+	}
 	return nil
 }
 
-func (s *OrderService) DeleteOrder(ctx context.Context, orderId int64) error {
+func (s *OrderService) DeleteOrder(ctx context.Context, orderId int64, version int64) error {
 	if orderId <= 0 {
-		return fmt.Errorf("invalid orderId: must be a positive number")
+		return fmt.Errorf("%w: invalid orderId: must be a positive number", entity.ErrInvalid)
+	}
+	if err := s.authorize(ctx, orderId); err != nil {
+		return err
+	}
+	before, err := s.repository.GetOrderById(ctx, orderId)
+	if err != nil {
+		return err
 	}
-	return s.repository.DeleteOrder(ctx, orderId)
+	if err := s.repository.DeleteOrder(ctx, orderId, version); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, auditService.EntityOrder, orderKey(&orderId), auditService.ActionDelete, before, nil)
+	return nil
+}
+
+// authorize lets customers act on their own orders and staff on every order.
+func (s *OrderService) authorize(ctx context.Context, orderId int64) error {
+	owner, err := s.repository.GetOrderOwner(ctx, orderId)
+	if err != nil {
+		return err
+	}
+	return authz.RequireSelfOr(ctx, owner, entity.RoleStaff)
+}
+
+func orderKey(orderId *int64) string {
+	if orderId == nil {
+		return ""
+	}
+	return strconv.FormatInt(*orderId, 10)
 }
 
 func (s *OrderService) GetOrderById(ctx context.Context, orderId int64) (petstore.Order, error) {
 	if orderId <= 0 {
-		return petstore.Order{}, fmt.Errorf("invalid orderId: must be a positive number")
+		return petstore.Order{}, fmt.Errorf("%w: invalid orderId: must be a positive number", entity.ErrInvalid)
+	}
+	if err := s.authorize(ctx, orderId); err != nil {
+		return petstore.Order{}, err
 	}
 	return s.repository.GetOrderById(ctx, orderId)
 }
+
+func (s *OrderService) GetVersionedOrder(ctx context.Context, orderId int64) (petstore.Order, int64, error) {
+	if orderId <= 0 {
+		return petstore.Order{}, 0, fmt.Errorf("%w: invalid orderId: must be a positive number", entity.ErrInvalid)
+	}
+	if err := s.authorize(ctx, orderId); err != nil {
+		return petstore.Order{}, 0, err
+	}
+	return s.repository.GetVersionedOrder(ctx, orderId)
+}
+
+func (s *OrderService) SnapshotInventory(ctx context.Context) error {
+	return s.repository.SnapshotInventory(ctx)
+}
+
+func (s *OrderService) GetInventoryHistory(ctx context.Context, from, to time.Time, interval string) ([]entity.InventoryPoint, error) {
+	if interval == "" {
+		interval = "day"
+	}
+	if !inventoryIntervals[interval] {
+		return nil, fmt.Errorf("%w: invalid interval: must be one of hour, day, week, month", entity.ErrInvalid)
+	}
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -30)
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: invalid range: from must be before to", entity.ErrInvalid)
+	}
+	return s.repository.GetInventoryHistory(ctx, from, to, interval)
+}
+
+func (s *OrderService) ExportOrders(ctx context.Context, filter entity.OrderFilter, each func(order entity.OrderRecord) error) error {
+	switch petstore.OrderStatus(filter.Status) {
+	case "", petstore.Placed, petstore.Approved, petstore.Delivered:
+	default:
+		return fmt.Errorf("%w: invalid status %q", entity.ErrInvalid, filter.Status)
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return fmt.Errorf("%w: from must be before to", entity.ErrInvalid)
+	}
+	return s.repository.ExportOrders(ctx, filter, each)
+}
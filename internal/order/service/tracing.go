@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"swagger_petstore/entity"
+	"swagger_petstore/petstore"
+	"swagger_petstore/tracing"
+	"time"
+)
+
+// tracedService starts a span around the methods of the service that can fail.
+type tracedService struct {
+	Servicer
+}
+
+// Traced wraps the service so that its calls are traced.
+func Traced(service Servicer) Servicer {
+	return tracedService{Servicer: service}
+}
+
+func (s tracedService) GetInventory(ctx context.Context) (_ map[string]int32, err error) {
+	ctx, span := tracing.Start(ctx, "OrderService.GetInventory")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.GetInventory(ctx)
+}
+
+func (s tracedService) PlaceOrder(ctx context.Context, order petstore.Order) (err error) {
+	ctx, span := tracing.Start(ctx, "OrderService.PlaceOrder")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.PlaceOrder(ctx, order)
+}
+
+func (s tracedService) DeleteOrder(ctx context.Context, orderId int64, version int64) (err error) {
+	ctx, span := tracing.Start(ctx, "OrderService.DeleteOrder")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.DeleteOrder(ctx, orderId, version)
+}
+
+func (s tracedService) GetOrderById(ctx context.Context, orderId int64) (_ petstore.Order, err error) {
+	ctx, span := tracing.Start(ctx, "OrderService.GetOrderById")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.GetOrderById(ctx, orderId)
+}
+
+func (s tracedService) GetVersionedOrder(ctx context.Context, orderId int64) (_ petstore.Order, _ int64, err error) {
+	ctx, span := tracing.Start(ctx, "OrderService.GetVersionedOrder")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.GetVersionedOrder(ctx, orderId)
+}
+
+func (s tracedService) SnapshotInventory(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "OrderService.SnapshotInventory")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.SnapshotInventory(ctx)
+}
+
+func (s tracedService) GetInventoryHistory(ctx context.Context, from, to time.Time, interval string) (_ []entity.InventoryPoint, err error) {
+	ctx, span := tracing.Start(ctx, "OrderService.GetInventoryHistory")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.GetInventoryHistory(ctx, from, to, interval)
+}
+
+func (s tracedService) ExportOrders(ctx context.Context, filter entity.OrderFilter, each func(order entity.OrderRecord) error) (err error) {
+	ctx, span := tracing.Start(ctx, "OrderService.ExportOrders")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.ExportOrders(ctx, filter, each)
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"swagger_petstore/entity"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type OutboxRepository interface {
+	Claim(ctx context.Context, limit int, maxAttempts int, lease time.Duration) ([]entity.Event, error)
+	MarkPublished(ctx context.Context, eventId int64) error
+	MarkFailed(ctx context.Context, eventId int64, cause string, retryIn time.Duration) error
+	Release(ctx context.Context, eventIds []int64) error
+	Sequence(ctx context.Context, limit int) (int, error)
+	LastSequenced(ctx context.Context) (int64, error)
+	GetSequenced(ctx context.Context, afterSeq int64, limit int) ([]entity.Event, error)
+}
+type Repository struct {
+	db *sqlx.DB
+}
+
+func NewOutboxRepository(db *sqlx.DB) OutboxRepository {
+	return &Repository{db: db}
+}
+
+// AddEvent writes an event to the outbox. exec should be the transaction of the change,
+// so the event is stored only if the change is committed.
+func AddEvent(ctx context.Context, exec sqlx.ExecerContext, eventType, entityId string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+	_, err = exec.ExecContext(ctx, `
+		INSERT INTO outbox (type, entity_id, payload)
+		VALUES ($1, $2, $3::jsonb)`,
+		eventType, entityId, string(data),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add %s event: %w", eventType, err)
+	}
+	return nil
+}
+
+// Claim leases up to limit unpublished events that are due, in order, and returns them.
+// The lease keeps other relays away from them until it runs out, so events left over by a
+// relay that stopped are picked up again. Publishing happens outside of any transaction.
+func (r *Repository) Claim(ctx context.Context, limit int, maxAttempts int, lease time.Duration) ([]entity.Event, error) {
+	events := []entity.Event{}
+	err := r.db.SelectContext(ctx, &events, `
+		UPDATE outbox SET next_attempt_at = NOW() + $3::interval
+		WHERE id IN (
+			SELECT id FROM outbox
+			WHERE published_at IS NULL AND attempts < $1 AND next_attempt_at <= NOW()
+			ORDER BY id
+			FOR UPDATE SKIP LOCKED
+			LIMIT $2
+		)
+		RETURNING id, type, entity_id, payload, occurred_at, attempts`,
+		maxAttempts, limit, interval(lease),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim outbox events: %w", err)
+	}
+	slices.SortFunc(events, func(a, b entity.Event) int { return cmp.Compare(a.Id, b.Id) })
+	return events, nil
+}
+
+func (r *Repository) MarkPublished(ctx context.Context, eventId int64) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE outbox SET attempts = attempts + 1, last_error = NULL, published_at = NOW()
+		WHERE id = $1`,
+		eventId,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox event published: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed counts a failed attempt, the event is due again after retryIn.
+func (r *Repository) MarkFailed(ctx context.Context, eventId int64, cause string, retryIn time.Duration) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE outbox SET attempts = attempts + 1, last_error = $2, next_attempt_at = NOW() + $3::interval
+		WHERE id = $1`,
+		eventId, cause, interval(retryIn),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox event failed: %w", err)
+	}
+	return nil
+}
+
+// Release ends the lease of events that were claimed but not published.
+func (r *Repository) Release(ctx context.Context, eventIds []int64) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE outbox SET next_attempt_at = NOW() WHERE id = ANY($1)`, pq.Array(eventIds))
+	if err != nil {
+		return fmt.Errorf("failed to release outbox events: %w", err)
+	}
+	return nil
+}
+
+// sequenceLock serializes Sequence across instances, so the numbers become visible in order.
+const sequenceLock = 21
+
+// Sequence numbers up to limit committed events that have no stream sequence yet, in the
+// order of their ids, and returns how many it numbered. An event is sequenced once it is
+// committed, whether or not it is published to the sinks. Events committed out of the
+// order of their ids get a later number, so readers that follow the sequence miss none.
+func (r *Repository) Sequence(ctx context.Context, limit int) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// The lock is held until the commit, a later batch can't become visible first.
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, sequenceLock); err != nil {
+		return 0, fmt.Errorf("failed to lock outbox sequence: %w", err)
+	}
+	res, err := tx.ExecContext(ctx, `
+		UPDATE outbox SET stream_seq = sequenced.seq
+		FROM (
+			SELECT id, nextval('outbox_stream_seq') AS seq
+			FROM (SELECT id FROM outbox WHERE stream_seq IS NULL ORDER BY id LIMIT $1) pending
+		) sequenced
+		WHERE outbox.id = sequenced.id`,
+		limit,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to sequence outbox events: %w", err)
+	}
+	sequenced, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(sequenced), tx.Commit()
+}
+
+// LastSequenced returns the last stream sequence given to an event, 0 when there is none.
+func (r *Repository) LastSequenced(ctx context.Context) (int64, error) {
+	var seq int64
+	if err := r.db.GetContext(ctx, &seq, `SELECT COALESCE(MAX(stream_seq), 0) FROM outbox`); err != nil {
+		return 0, fmt.Errorf("failed to get last outbox sequence: %w", err)
+	}
+	return seq, nil
+}
+
+// GetSequenced returns up to limit events with a stream sequence greater than afterSeq, in order.
+func (r *Repository) GetSequenced(ctx context.Context, afterSeq int64, limit int) ([]entity.Event, error) {
+	events := []entity.Event{}
+	err := r.db.SelectContext(ctx, &events, `
+		SELECT id, type, entity_id, payload, occurred_at, attempts, stream_seq
+		FROM outbox
+		WHERE stream_seq > $1
+		ORDER BY stream_seq
+		LIMIT $2`,
+		afterSeq, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sequenced events: %w", err)
+	}
+	return events, nil
+}
+
+// interval formats a duration as a postgres interval.
+func interval(d time.Duration) string {
+	return fmt.Sprintf("%d milliseconds", d.Milliseconds())
+}
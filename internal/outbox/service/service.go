@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"swagger_petstore/backoff"
+	"swagger_petstore/entity"
+	"swagger_petstore/event"
+	"swagger_petstore/internal/outbox/repository"
+	"time"
+)
+
+const (
+	relayBatch       = 100
+	relayMaxAttempts = 10
+	// relayLease outlasts the publishing of a batch to slow sinks.
+	relayLease        = 10 * time.Minute
+	relayRetryBackoff = 5 * time.Second
+	maxRelayBackoff   = 5 * time.Minute
+	replayBatch       = 500
+	sequenceBatch     = 500
+	// subscriberBuffer is the number of events a stream can fall behind before it is dropped.
+	subscriberBuffer = 256
+)
+
+// ErrLagging ends a stream that fell behind the sequenced events, the client should resume
+// from the last event it received.
+var ErrLagging = errors.New("event stream fell behind")
+
+type Servicer interface {
+	Relay(ctx context.Context) error
+	Follow(ctx context.Context) error
+	Stream(ctx context.Context, afterSeq int64, send func(event entity.Event) error) error
+}
+type OutboxService struct {
+	repository repository.OutboxRepository
+	bus        *event.Bus
+	sinks      []event.Sink
+	// followed is the stream sequence of the last event passed to the bus, -1 until Follow
+	// first runs. Follow is not run concurrently.
+	followed int64
+}
+
+// NewOutboxService publishes relayed events to the sinks. Streams follow the outbox itself
+// through the bus fed by Follow, so they don't wait for the sinks, and every instance
+// streams every event whichever instance relays it.
+func NewOutboxService(repository repository.OutboxRepository, bus *event.Bus, sinks ...event.Sink) *OutboxService {
+	return &OutboxService{repository: repository, bus: bus, sinks: sinks, followed: -1}
+}
+
+// Relay publishes the due outbox events to every sink until the outbox is drained. A failed
+// event is published again to all sinks after a backoff. The outcome of an event is stored
+// even when ctx is cancelled meanwhile, the events left unpublished are released.
+func (s *OutboxService) Relay(ctx context.Context) error {
+	store := context.WithoutCancel(ctx)
+	for {
+		events, err := s.repository.Claim(ctx, relayBatch, relayMaxAttempts, relayLease)
+		if err != nil {
+			return err
+		}
+		for i, e := range events {
+			if ctx.Err() != nil {
+				ids := make([]int64, 0, len(events)-i)
+				for _, left := range events[i:] {
+					ids = append(ids, left.Id)
+				}
+				return s.repository.Release(store, ids)
+			}
+			if err := s.publish(ctx, e); err != nil {
+				retryIn := backoff.Exponential(relayRetryBackoff, maxRelayBackoff, e.Attempts+1)
+				if err := s.repository.MarkFailed(store, e.Id, err.Error(), retryIn); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := s.repository.MarkPublished(store, e.Id); err != nil {
+				return err
+			}
+		}
+		if len(events) < relayBatch {
+			return nil
+		}
+	}
+}
+
+func (s *OutboxService) publish(ctx context.Context, e entity.Event) error {
+	var errs []error
+	for _, sink := range s.sinks {
+		if err := sink.Publish(ctx, e); err != nil {
+			errs = append(errs, fmt.Errorf("%T: %w", sink, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Follow numbers the events committed to the outbox since the last call and passes the newly
+// numbered events of every instance to the bus, in the order of their sequence. The first
+// call starts from the events numbered from then on.
+func (s *OutboxService) Follow(ctx context.Context) error {
+	if s.followed < 0 {
+		last, err := s.repository.LastSequenced(ctx)
+		if err != nil {
+			return err
+		}
+		s.followed = last
+	}
+
+	for {
+		sequenced, err := s.repository.Sequence(ctx, sequenceBatch)
+		if err != nil {
+			return err
+		}
+		if sequenced < sequenceBatch {
+			break
+		}
+	}
+
+	for {
+		events, err := s.repository.GetSequenced(ctx, s.followed, replayBatch)
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			s.bus.Publish(ctx, e)
+			s.followed = e.Seq
+		}
+		if len(events) < replayBatch {
+			return nil
+		}
+	}
+}
+
+// Stream calls send for every event sequenced after afterSeq until ctx is cancelled. Events
+// already sequenced are replayed from the outbox first, a negative afterSeq skips them.
+func (s *OutboxService) Stream(ctx context.Context, afterSeq int64, send func(event entity.Event) error) error {
+	// Subscribing before the replay makes sure no event is missed in between.
+	events, cancel := s.bus.Subscribe(subscriberBuffer)
+	defer cancel()
+
+	for afterSeq >= 0 {
+		replayed, err := s.repository.GetSequenced(ctx, afterSeq, replayBatch)
+		if err != nil {
+			return err
+		}
+		for _, e := range replayed {
+			if err := send(e); err != nil {
+				return err
+			}
+			afterSeq = e.Seq
+		}
+		if len(replayed) < replayBatch {
+			break
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e, ok := <-events:
+			if !ok {
+				return ErrLagging
+			}
+			if e.Seq <= afterSeq {
+				continue
+			}
+			if err := send(e); err != nil {
+				return err
+			}
+		}
+	}
+}
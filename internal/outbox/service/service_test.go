@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"swagger_petstore/entity"
+	"swagger_petstore/event"
+	"swagger_petstore/internal/outbox/repository"
+	"testing"
+)
+
+// fakeRepository keeps the outbox in memory. Events get their sequence in the order they are
+// committed, which is not the order of their ids.
+type fakeRepository struct {
+	repository.OutboxRepository
+	committed []entity.Event
+	next      int64
+}
+
+func (r *fakeRepository) commit(ids ...int64) {
+	for _, id := range ids {
+		r.committed = append(r.committed, entity.Event{Id: id})
+	}
+}
+
+func (r *fakeRepository) Sequence(_ context.Context, limit int) (int, error) {
+	sequenced := 0
+	for i := range r.committed {
+		if r.committed[i].Seq == 0 && sequenced < limit {
+			r.next++
+			r.committed[i].Seq = r.next
+			sequenced++
+		}
+	}
+	return sequenced, nil
+}
+
+func (r *fakeRepository) LastSequenced(context.Context) (int64, error) { return r.next, nil }
+
+func (r *fakeRepository) GetSequenced(_ context.Context, afterSeq int64, limit int) ([]entity.Event, error) {
+	var events []entity.Event
+	for _, e := range r.committed {
+		if e.Seq > afterSeq && len(events) < limit {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+var errStop = errors.New("stop")
+
+func TestFollow(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeRepository{}
+	s := NewOutboxService(repo, event.NewBus())
+	events, cancel := s.bus.Subscribe(subscriberBuffer)
+	defer cancel()
+
+	// Events sequenced before the first Follow are left to the replay of streams.
+	repo.commit(1)
+	repo.Sequence(ctx, sequenceBatch)
+	repo.commit(3)
+	if err := s.Follow(ctx); err != nil {
+		t.Fatalf("Follow: %v", err)
+	}
+	repo.commit(2, 4)
+	if err := s.Follow(ctx); err != nil {
+		t.Fatalf("Follow: %v", err)
+	}
+
+	var got []int64
+	for len(events) > 0 {
+		got = append(got, (<-events).Id)
+	}
+	if len(got) != 3 || got[0] != 3 || got[1] != 2 || got[2] != 4 {
+		t.Errorf("followed events %v, want [3 2 4]", got)
+	}
+}
+
+func TestStreamResumesBySequence(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeRepository{}
+	s := NewOutboxService(repo, event.NewBus())
+	repo.commit(1, 3)
+	repo.Sequence(ctx, sequenceBatch)
+	// Event 2 is committed after 3 was streamed.
+	repo.commit(2, 4)
+	repo.Sequence(ctx, sequenceBatch)
+
+	var got []int64
+	err := s.Stream(ctx, 2, func(e entity.Event) error {
+		got = append(got, e.Id)
+		if len(got) == 2 {
+			return errStop
+		}
+		return nil
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("Stream = %v", err)
+	}
+	if len(got) != 2 || got[0] != 2 || got[1] != 4 {
+		t.Errorf("streamed events %v after event 3, want [2 4]", got)
+	}
+}
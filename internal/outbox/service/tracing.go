@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+	"swagger_petstore/tracing"
+)
+
+// tracedService starts a span around the methods of the service that can fail.
+type tracedService struct {
+	Servicer
+}
+
+// Traced wraps the service so that its calls are traced.
+func Traced(service Servicer) Servicer {
+	return tracedService{Servicer: service}
+}
+
+func (s tracedService) Relay(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "OutboxService.Relay")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.Relay(ctx)
+}
+
+func (s tracedService) Follow(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "OutboxService.Follow")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.Follow(ctx)
+}
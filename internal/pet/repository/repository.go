@@ -3,11 +3,15 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strconv"
-	"strings"
+	"swagger_petstore/entity"
+	outbox "swagger_petstore/internal/outbox/repository"
 	"swagger_petstore/middleware"
 	"swagger_petstore/petstore"
+	"swagger_petstore/postgres"
+	"time"
 
 	"github.com/go-chi/jwtauth/v5"
 	"github.com/lib/pq"
@@ -16,13 +20,23 @@ import (
 )
 
 type PetsRepository interface {
-	AddPet(ctx context.Context, pet petstore.Pet) error
-	UpdatePet(ctx context.Context, pet petstore.Pet) error
-	FindPetsByStatus(ctx context.Context, status petstore.FindPetsByStatusParams) ([]petstore.Pet, error)
-	FindPetsByTags(ctx context.Context, status petstore.FindPetsByTagsParams) ([]petstore.Pet, error)
-	DeletePet(ctx context.Context, petId int64, params petstore.DeletePetParams) error
+	AddPet(ctx context.Context, pet petstore.Pet) (int64, error)
+	ImportPets(ctx context.Context, pets []petstore.Pet, dryRun bool) ([]entity.ImportResult, error)
+	UpdatePet(ctx context.Context, pet petstore.Pet, version int64) error
+	FindPetsByStatus(ctx context.Context, status petstore.FindPetsByStatusParams, includeDeleted bool) ([]petstore.Pet, error)
+	FindPetsByTags(ctx context.Context, query entity.TagQuery, includeDeleted bool) ([]petstore.Pet, error)
+	DeletePet(ctx context.Context, petId int64, params petstore.DeletePetParams, version int64) error
 	GetPetById(ctx context.Context, petId int64) (petstore.Pet, error)
+	GetVersionedPet(ctx context.Context, petId int64, includeDeleted bool) (petstore.Pet, int64, error)
+	RestorePet(ctx context.Context, petId int64) error
+	PurgeDeletedPets(ctx context.Context, before time.Time) (int64, error)
 	UpdatePetWithForm(ctx context.Context, petId int64, params petstore.UpdatePetWithFormParams) error
+	FindPetsByCategory(ctx context.Context, categoryId int64) ([]petstore.Pet, error)
+	SearchPets(ctx context.Context, search entity.PetSearch) ([]petstore.Pet, error)
+	ExportPets(ctx context.Context, search entity.PetSearch, each func(pet entity.PetRecord) error) error
+	CountPets(ctx context.Context) (int64, error)
+	ReindexSearch(ctx context.Context, afterId int64, limit int) (int64, int, error)
+	PatchPet(ctx context.Context, petId int64, version int64, apply func(pet *petstore.Pet) error) (petstore.Pet, int64, error)
 }
 type Repository struct {
 	db *sqlx.DB
@@ -32,22 +46,76 @@ func NewRepository(db *sqlx.DB) PetsRepository {
 	return &Repository{db: db}
 }
 
-func (r *Repository) AddPet(ctx context.Context, pet petstore.Pet) error {
+func (r *Repository) AddPet(ctx context.Context, pet petstore.Pet) (int64, error) {
 	tx, err := r.db.Begin()
 	if err != nil {
-		return err
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	petID, err := insertPet(ctx, tx, pet)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return petID, nil
+}
+
+// ImportPets inserts the pets in one transaction. A pet that fails is rolled back to its
+// savepoint and reported without aborting the others. With dryRun nothing is committed.
+func (r *Repository) ImportPets(ctx context.Context, pets []petstore.Pet, dryRun bool) ([]entity.ImportResult, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
 	}
 	defer tx.Rollback()
 
-	var categoryID *int64
-	if pet.Category != nil && pet.Category.Name != nil {
-		err := tx.QueryRow(
-			"INSERT INTO categories (name) VALUES ($1) RETURNING id",
-			*pet.Category.Name,
-		).Scan(&categoryID)
+	results := make([]entity.ImportResult, len(pets))
+	for i, pet := range pets {
+		results[i].Name = pet.Name
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT pet_import"); err != nil {
+			return nil, err
+		}
+
+		petID, err := insertPet(ctx, tx, pet)
 		if err != nil {
-			return err
+			if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT pet_import"); err != nil {
+				return nil, err
+			}
+			results[i].Status = entity.ImportFailed
+			results[i].Error = err.Error()
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT pet_import"); err != nil {
+			return nil, err
 		}
+
+		if dryRun {
+			results[i].Status = entity.ImportValid
+		} else {
+			results[i].Status = entity.ImportCreated
+			results[i].Id = &petID
+		}
+	}
+
+	if dryRun {
+		return results, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// insertPet inserts the pet with its category and tags and returns the new id.
+func insertPet(ctx context.Context, tx *sql.Tx, pet petstore.Pet) (int64, error) {
+	categoryID, err := upsertCategory(tx, pet.Category)
+	if err != nil {
+		return 0, err
 	}
 
 	var petID int64
@@ -56,51 +124,22 @@ func (r *Repository) AddPet(ctx context.Context, pet petstore.Pet) error {
 		pet.Name, categoryID, pq.Array(pet.PhotoUrls), pet.Status,
 	).Scan(&petID)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	if pet.Tags != nil {
-		for _, tag := range *pet.Tags {
-			if tag.Name != nil {
-				var tagID int64
-
-				err := tx.QueryRow(
-					"SELECT id FROM tags WHERE name = $1", *tag.Name,
-				).Scan(&tagID)
-
-				if err != nil && err != sql.ErrNoRows {
-					return err
-				}
-
-				if err == sql.ErrNoRows {
-					err = tx.QueryRow(
-						"INSERT INTO tags (name) VALUES ($1) RETURNING id",
-						*tag.Name,
-					).Scan(&tagID)
-					if err != nil {
-						return err
-					}
-				}
-
-				_, err = tx.Exec(
-					"INSERT INTO pet_tags (pet_id, tag_id) VALUES ($1, $2)",
-					petID, tagID,
-				)
-				if err != nil {
-					return err
-				}
-			}
-		}
+	if err := insertPetTags(tx, petID, pet.Tags); err != nil {
+		return 0, err
 	}
 
-	if err := tx.Commit(); err != nil {
-		return err
+	pet.Id = &petID
+	if err := outbox.AddEvent(ctx, tx, entity.EventPetAdded, strconv.FormatInt(petID, 10), pet); err != nil {
+		return 0, err
 	}
-
-	return nil
+	return petID, nil
 }
 
-func (r *Repository) UpdatePet(ctx context.Context, pet petstore.Pet) error {
+// UpdatePet overwrites the pet, a non-zero version must match the stored one.
+func (r *Repository) UpdatePet(ctx context.Context, pet petstore.Pet, version int64) error {
 	if pet.Id == nil {
 		return fmt.Errorf("pet ID is required for update")
 	}
@@ -111,227 +150,304 @@ func (r *Repository) UpdatePet(ctx context.Context, pet petstore.Pet) error {
 	}
 	defer tx.Rollback()
 
-	_, err = tx.Exec(`
-        UPDATE pets 
-        SET name = $1, status = $2, photoUrls = $3, category_id = $4
-        WHERE id = $5`,
-		pet.Name, pet.Status, pq.Array(pet.PhotoUrls), nil, *pet.Id,
-	)
+	var current int64
+	err = tx.QueryRowContext(ctx, "SELECT version FROM pets WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", *pet.Id).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("pet %d: %w", *pet.Id, entity.ErrNotFound)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to update pet: %v", err)
+		return fmt.Errorf("failed to lock pet: %v", err)
+	}
+	if err := checkVersion(current, version); err != nil {
+		return err
 	}
 
-	if pet.Category != nil {
-		categoryID := new(int64)
-
-		if *pet.Category.Id != 0 {
-			categoryID = pet.Category.Id
-		} else if pet.Category.Name != nil {
-			err := tx.QueryRow(`
-                INSERT INTO categories (name) 
-                VALUES ($1) 
-                ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
-                RETURNING id`,
-				*pet.Category.Name,
-			).Scan(&categoryID)
-			if err != nil {
-				return fmt.Errorf("failed to upsert category: %v", err)
-			}
-		}
+	if err := savePet(ctx, tx, pet); err != nil {
+		return err
+	}
 
-		_, err = tx.Exec(`
-            UPDATE pets SET category_id = $1 WHERE id = $2`,
-			categoryID, *pet.Id,
-		)
-		if err != nil {
-			return fmt.Errorf("failed to update pet category: %v", err)
-		}
-	} else {
+	return tx.Commit()
+}
 
-		_, err = tx.Exec(`
-            UPDATE pets SET category_id = NULL WHERE id = $1`,
-			*pet.Id,
-		)
-		if err != nil {
-			return fmt.Errorf("failed to clear pet category: %v", err)
-		}
+// PatchPet locks the pet, passes it to apply and stores the modified pet in the same transaction.
+// It returns the stored pet and its new version.
+func (r *Repository) PatchPet(ctx context.Context, petId int64, version int64, apply func(pet *petstore.Pet) error) (petstore.Pet, int64, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return petstore.Pet{}, 0, err
 	}
+	defer tx.Rollback()
 
-	_, err = tx.Exec(`DELETE FROM pet_tags WHERE pet_id = $1`, *pet.Id)
+	pet, current, err := getPet(ctx, tx, petId, false, "FOR UPDATE")
 	if err != nil {
-		return fmt.Errorf("failed to delete old tags: %v", err)
+		return petstore.Pet{}, 0, err
+	}
+	if err := checkVersion(current, version); err != nil {
+		return petstore.Pet{}, 0, err
 	}
 
-	if pet.Tags != nil {
-		for _, tag := range *pet.Tags {
-			var tagID int64
-
-			if *tag.Id != 0 {
-				tagID = *tag.Id
-			} else if tag.Name != nil {
-				// Ищем или создаем тег по имени
-				err := tx.QueryRow(`
-                    INSERT INTO tags (name) 
-                    VALUES ($1) 
-                    ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
-                    RETURNING id`,
-					*tag.Name,
-				).Scan(&tagID)
-				if err != nil {
-					return fmt.Errorf("failed to upsert tag: %v", err)
-				}
-			}
+	if err := apply(&pet); err != nil {
+		return petstore.Pet{}, 0, err
+	}
+	pet.Id = &petId
 
-			if tagID != 0 {
-				_, err = tx.Exec(`
-                    INSERT INTO pet_tags (pet_id, tag_id) VALUES ($1, $2)`,
-					*pet.Id, tagID,
-				)
-				if err != nil {
-					return fmt.Errorf("failed to insert pet tag: %v", err)
-				}
-			}
-		}
+	if err := savePet(ctx, tx.Tx, pet); err != nil {
+		return petstore.Pet{}, 0, err
 	}
 
-	return tx.Commit()
+	if err := tx.Commit(); err != nil {
+		return petstore.Pet{}, 0, err
+	}
+	return pet, current + 1, nil
 }
 
-func (r *Repository) FindPetsByStatus(ctx context.Context, status petstore.FindPetsByStatusParams) ([]petstore.Pet, error) {
-	rows, err := r.db.Query(`
-		SELECT p.id, p.name, p.photoUrls, p.category_id, p.status
-		FROM pets p
-		WHERE p.status = $1
-	`, status.Status)
+// checkVersion compares the stored version with the expected one, zero accepts any version.
+func checkVersion(current, expected int64) error {
+	if expected != 0 && current != expected {
+		return fmt.Errorf("pet version is %d, not %d: %w", current, expected, entity.ErrPreconditionFailed)
+	}
+	return nil
+}
 
+// savePet overwrites the pet row, its category and its tags.
+func savePet(ctx context.Context, tx *sql.Tx, pet petstore.Pet) error {
+	categoryID, err := upsertCategory(tx, pet.Category)
 	if err != nil {
-		return []petstore.Pet{}, err
+		return fmt.Errorf("failed to upsert category: %v", err)
+	}
+
+	var oldStatus sql.NullString
+	err = tx.QueryRowContext(ctx, `
+        UPDATE pets p
+        SET name = $1, status = $2, photoUrls = $3, category_id = $4, version = p.version + 1
+        FROM (SELECT id, status FROM pets WHERE id = $5 FOR UPDATE) old
+        WHERE p.id = old.id
+        RETURNING old.status`,
+		pet.Name, pet.Status, pq.Array(pet.PhotoUrls), categoryID, *pet.Id,
+	).Scan(&oldStatus)
+	if err != nil {
+		return fmt.Errorf("failed to update pet: %v", err)
 	}
 
-	var pets []petstore.Pet
-	for rows.Next() {
-		var pet petstore.Pet
-		var categoryID sql.NullInt64
-		var status sql.NullString
-		var url []string
-
-		err := rows.Scan(
-			&pet.Id,
-			&pet.Name,
-			pq.Array(&url),
-			&categoryID,
-			&status,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan pet row: %v", err)
-		}
-		pet.PhotoUrls = url
+	var newStatus string
+	if pet.Status != nil {
+		newStatus = string(*pet.Status)
+	}
+	if err := addStatusChange(ctx, tx, *pet.Id, oldStatus.String, newStatus); err != nil {
+		return err
+	}
 
-		if status.Valid {
-			s := petstore.PetStatus(status.String)
-			pet.Status = &s
-		}
+	_, err = tx.Exec(`DELETE FROM pet_tags WHERE pet_id = $1`, *pet.Id)
+	if err != nil {
+		return fmt.Errorf("failed to delete old tags: %v", err)
+	}
 
-		if categoryID.Valid {
-			category := petstore.Category{
-				Id:   new(int64),
-				Name: new(string),
-			}
-			*category.Id = categoryID.Int64
-			var name sql.NullString
-			err := r.db.QueryRow(`
-				SELECT name FROM categories WHERE id = $1
-			`, categoryID.Int64).Scan(&name)
-			if err != nil && err != sql.ErrNoRows {
-				return []petstore.Pet{}, err
-			}
-			if name.Valid {
-				category.Name = &name.String
-			}
-			pet.Category = &category
-		}
+	return insertPetTags(tx, *pet.Id, pet.Tags)
+}
 
-		pets = append(pets, pet)
+func (r *Repository) FindPetsByStatus(ctx context.Context, status petstore.FindPetsByStatusParams, includeDeleted bool) ([]petstore.Pet, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT p.id, p.name, p.photoUrls, p.status, c.id, c.name
+		FROM pets p
+		LEFT JOIN categories c ON p.category_id = c.id
+		WHERE p.status = $1`+notDeleted(includeDeleted)+`
+		ORDER BY p.id
+	`, status.Status)
+	if err != nil {
+		return []petstore.Pet{}, err
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error during rows iteration: %v", err)
-	}
+	return r.collectPets(ctx, rows)
+}
 
-	for i := range pets {
-		if pets[i].Id == nil {
-			continue
-		}
+// FindPetsByTags returns pets having all (or any) of tags.Tags and none of tags.Exclude.
+func (r *Repository) FindPetsByTags(ctx context.Context, tags entity.TagQuery, includeDeleted bool) ([]petstore.Pet, error) {
+	var args []interface{}
+	query := `
+        SELECT p.id, p.name, p.photoUrls, p.status, c.id, c.name
+        FROM pets p
+        LEFT JOIN categories c ON p.category_id = c.id
+        WHERE TRUE`
+	if len(tags.Tags) > 0 {
+		query += withTags(&args, tags.Tags, tags.Match == entity.TagMatchAll)
+	}
+	if len(tags.Exclude) > 0 {
+		args = append(args, pq.Array(tags.Exclude))
+		query += fmt.Sprintf(`
+        AND NOT EXISTS (
+            SELECT 1
+            FROM pet_tags pt
+            JOIN tags t ON pt.tag_id = t.id
+            WHERE pt.pet_id = p.id AND lower(t.name) = ANY($%d)
+        )`, len(args))
+	}
+	query += notDeleted(includeDeleted) + `
+        ORDER BY p.id`
 
-		tagRows, err := r.db.Query(`
-            SELECT t.id, t.name 
-            FROM tags t
-            JOIN pet_tags pt ON t.id = pt.tag_id
-            WHERE pt.pet_id = $1`,
-			*pets[i].Id,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to query pet tags: %v", err)
-		}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pets by tags: %v", err)
+	}
 
-		var tagsList []petstore.Tag
-		for tagRows.Next() {
-			var tag petstore.Tag
-			var name sql.NullString
-			if err := tagRows.Scan(&tag.Id, &name); err != nil {
-				tagRows.Close()
-				return nil, fmt.Errorf("failed to scan tag: %v", err)
-			}
-			if name.Valid {
-				tag.Name = &name.String
-			}
-			tagsList = append(tagsList, tag)
-		}
-		tagRows.Close()
+	return r.collectPets(ctx, rows)
+}
 
-		if len(tagsList) > 0 {
-			pets[i].Tags = &tagsList
+// ExportPets streams active pets matching the search, ordered by id. An empty query
+// matches every pet, Limit and Offset are ignored.
+func (r *Repository) ExportPets(ctx context.Context, search entity.PetSearch, each func(pet entity.PetRecord) error) error {
+	var args []interface{}
+	query := `
+        SELECT p.id, p.name, COALESCE(p.status, ''), COALESCE(c.name, ''),
+            ARRAY(
+                SELECT t.name
+                FROM pet_tags pt
+                JOIN tags t ON pt.tag_id = t.id
+                WHERE pt.pet_id = p.id
+                ORDER BY t.name
+            ),
+            COALESCE(p.photoUrls, '{}')
+        FROM pets p
+        LEFT JOIN categories c ON p.category_id = c.id
+        WHERE p.deleted_at IS NULL`
+	if search.Query != "" {
+		args = append(args, search.Query)
+		query += fmt.Sprintf(`
+        AND (p.search_document @@ plainto_tsquery('simple', $%d) OR $%d <%% p.search_text)`, len(args), len(args))
+	}
+	query += searchFilters(&args, search) + `
+        ORDER BY p.id`
+
+	err := postgres.EachRow(ctx, r.db, query, args, func(rows *sqlx.Rows) error {
+		var pet entity.PetRecord
+		if err := rows.Scan(&pet.Id, &pet.Name, &pet.Status, &pet.Category, pq.Array(&pet.Tags), pq.Array(&pet.PhotoUrls)); err != nil {
+			return fmt.Errorf("failed to scan pet row: %v", err)
 		}
+		return each(pet)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export pets: %w", err)
 	}
+	return nil
+}
 
-	return pets, nil
+// CountPets returns the number of pets, deleted ones included.
+func (r *Repository) CountPets(ctx context.Context) (int64, error) {
+	var count int64
+	if err := r.db.GetContext(ctx, &count, "SELECT COUNT(*) FROM pets"); err != nil {
+		return 0, fmt.Errorf("failed to count pets: %w", err)
+	}
+	return count, nil
 }
 
-func (r *Repository) FindPetsByTags(ctx context.Context, params petstore.FindPetsByTagsParams) ([]petstore.Pet, error) {
-	if len(*params.Tags) == 0 {
-		return nil, fmt.Errorf("tags list cannot be empty")
+// ReindexSearch rebuilds the search document of up to limit pets with ids above afterId.
+// It returns the last reindexed id and the number of pets reindexed.
+func (r *Repository) ReindexSearch(ctx context.Context, afterId int64, limit int) (int64, int, error) {
+	var batch struct {
+		LastId sql.NullInt64 `db:"last_id"`
+		Count  int           `db:"count"`
+	}
+	err := r.db.GetContext(ctx, &batch, `
+        WITH batch AS (
+            SELECT id FROM pets WHERE id > $1 ORDER BY id LIMIT $2
+        ), refreshed AS (
+            SELECT refresh_pet_search(ARRAY(SELECT id FROM batch))
+        )
+        SELECT MAX(b.id) AS last_id, COUNT(b.id) AS count
+        FROM refreshed, batch b`,
+		afterId, limit,
+	)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to reindex pets: %w", err)
 	}
+	return batch.LastId.Int64, batch.Count, nil
+}
 
-	placeholders := make([]string, len(*params.Tags))
-	for i := range placeholders {
-		placeholders[i] = fmt.Sprintf("$%d", i+1)
+// searchFilters appends the status, category and tag conditions of the search.
+func searchFilters(args *[]interface{}, search entity.PetSearch) string {
+	var conditions string
+	if search.Status != "" {
+		*args = append(*args, search.Status)
+		conditions += fmt.Sprintf(" AND p.status = $%d", len(*args))
 	}
+	if search.CategoryId != 0 {
+		*args = append(*args, search.CategoryId)
+		conditions += fmt.Sprintf(" AND p.category_id = $%d", len(*args))
+	}
+	if len(search.Tags) > 0 {
+		conditions += withTags(args, search.Tags, true)
+	}
+	return conditions
+}
 
-	query := `
-        SELECT p.id, p.name, p.photoUrls, p.status, c.id, c.name
-        FROM pets p
-        LEFT JOIN categories c ON p.category_id = c.id
-        WHERE p.id IN (
+// withTags appends the condition keeping pets that have all or any of the lower-cased,
+// distinct tags, and adds its arguments to args.
+func withTags(args *[]interface{}, tags []string, all bool) string {
+	*args = append(*args, pq.Array(tags))
+	condition := fmt.Sprintf(`
+        AND p.id IN (
             SELECT pt.pet_id
             FROM pet_tags pt
             JOIN tags t ON pt.tag_id = t.id
-            WHERE t.name IN (` + strings.Join(placeholders, ", ") + `)
-            GROUP BY pt.pet_id
-            HAVING COUNT(DISTINCT t.name) = ` + strconv.Itoa(len(placeholders)) + `
+            WHERE lower(t.name) = ANY($%d)
+            GROUP BY pt.pet_id`, len(*args))
+	if all {
+		*args = append(*args, len(tags))
+		condition += fmt.Sprintf(`
+            HAVING COUNT(DISTINCT lower(t.name)) = $%d`, len(*args))
+	}
+	return condition + `
         )`
-	p := make([]interface{}, len(*params.Tags))
-	for i, tag := range *params.Tags {
-		p[i] = tag
+}
+
+func (r *Repository) FindPetsByCategory(ctx context.Context, categoryId int64) ([]petstore.Pet, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT p.id, p.name, p.photoUrls, p.status, c.id, c.name
+        FROM pets p
+        JOIN categories c ON p.category_id = c.id
+        WHERE c.id = $1 AND p.deleted_at IS NULL
+        ORDER BY p.id`,
+		categoryId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pets by category: %v", err)
 	}
-	// p[len(*params.Tags)] = len(*params.Tags)
 
-	rows, err := r.db.Query(query, p...)
+	return r.collectPets(ctx, rows)
+}
+
+// SearchPets matches the query against the name, category and tags of active pets
+// using full-text search, falling back to trigram similarity for typos and partial words.
+// The best matches come first.
+func (r *Repository) SearchPets(ctx context.Context, search entity.PetSearch) ([]petstore.Pet, error) {
+	args := []interface{}{search.Query}
+	query := `
+        SELECT p.id, p.name, p.photoUrls, p.status, c.id, c.name
+        FROM pets p
+        LEFT JOIN categories c ON p.category_id = c.id,
+        plainto_tsquery('simple', $1) q
+        WHERE (p.search_document @@ q OR $1 <% p.search_text) AND p.deleted_at IS NULL`
+
+	query += searchFilters(&args, search)
+
+	args = append(args, search.Limit, search.Offset)
+	query += fmt.Sprintf(`
+        ORDER BY ts_rank(p.search_document, q) + word_similarity($1, p.search_text) DESC, p.id
+        LIMIT $%d OFFSET $%d`, len(args)-1, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query pets by tags: %v", err)
+		return nil, fmt.Errorf("failed to search pets: %v", err)
 	}
+
+	return r.collectPets(ctx, rows)
+}
+
+// collectPets scans rows of (id, name, photoUrls, status, category id, category name)
+// and attaches the tags of every pet.
+func (r *Repository) collectPets(ctx context.Context, rows *sql.Rows) ([]petstore.Pet, error) {
 	defer rows.Close()
 
-	var pets []petstore.Pet
+	pets := []petstore.Pet{}
 	for rows.Next() {
 		var pet petstore.Pet
 		var categoryID sql.NullInt64
@@ -373,13 +489,14 @@ func (r *Repository) FindPetsByTags(ctx context.Context, params petstore.FindPet
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error during rows iteration: %v", err)
 	}
+	rows.Close()
 
 	for i := range pets {
 		if pets[i].Id == nil {
 			continue
 		}
 
-		tagRows, err := r.db.Query(`
+		tagRows, err := r.db.QueryContext(ctx, `
             SELECT t.id, t.name 
             FROM tags t
             JOIN pet_tags pt ON t.id = pt.tag_id
@@ -413,8 +530,74 @@ func (r *Repository) FindPetsByTags(ctx context.Context, params petstore.FindPet
 	return pets, nil
 }
 
-func (r *Repository) DeletePet(ctx context.Context, petId int64, params petstore.DeletePetParams) error {
-	var exists bool
+// insertPetTags links the tags to the pet, creating tags that do not exist yet.
+func insertPetTags(tx *sql.Tx, petID int64, tags *[]petstore.Tag) error {
+	if tags == nil {
+		return nil
+	}
+
+	for _, tag := range *tags {
+		var tagID int64
+
+		if tag.Name != nil && *tag.Name != "" {
+			err := tx.QueryRow(`
+                INSERT INTO tags (name) 
+                VALUES ($1) 
+                ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+                RETURNING id`,
+				*tag.Name,
+			).Scan(&tagID)
+			if err != nil {
+				return fmt.Errorf("failed to upsert tag: %v", err)
+			}
+		} else if tag.Id != nil {
+			tagID = *tag.Id
+		}
+
+		if tagID != 0 {
+			_, err := tx.Exec(`
+                INSERT INTO pet_tags (pet_id, tag_id) VALUES ($1, $2)
+                ON CONFLICT DO NOTHING`,
+				petID, tagID,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to insert pet tag: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// upsertCategory resolves the category of a pet to an id, reusing the existing
+// row with the same name. A nil result means the pet has no category.
+func upsertCategory(tx *sql.Tx, category *petstore.Category) (*int64, error) {
+	if category == nil {
+		return nil, nil
+	}
+	if category.Name == nil || *category.Name == "" {
+		if category.Id != nil && *category.Id != 0 {
+			return category.Id, nil
+		}
+		return nil, nil
+	}
+
+	var categoryID int64
+	err := tx.QueryRow(`
+        INSERT INTO categories (name) 
+        VALUES ($1) 
+        ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+        RETURNING id`,
+		*category.Name,
+	).Scan(&categoryID)
+	if err != nil {
+		return nil, err
+	}
+	return &categoryID, nil
+}
+
+func (r *Repository) DeletePet(ctx context.Context, petId int64, params petstore.DeletePetParams, version int64) error {
+	var current int64
+	var status sql.NullString
 
 	if params.ApiKey != nil {
 		apiKey := *params.ApiKey
@@ -424,41 +607,124 @@ func (r *Repository) DeletePet(ctx context.Context, petId int64, params petstore
 		}
 	}
 
-	err := r.db.QueryRowxContext(ctx, `
-        SELECT EXISTS(SELECT 1 FROM pets WHERE id = $1)`,
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowxContext(ctx, `
+        SELECT version, status FROM pets WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`,
 		petId,
-	).Scan(&exists)
+	).Scan(&current, &status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("pet with id %d: %w", petId, entity.ErrNotFound)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to check pet existence: %v", err)
 	}
-	if !exists {
-		return fmt.Errorf("pet with id %d not found", petId)
+	if err := checkVersion(current, version); err != nil {
+		return err
 	}
 
-	_, err = r.db.ExecContext(ctx, `DELETE FROM pets WHERE id = $1`, petId)
+	_, err = tx.ExecContext(ctx, `
+        UPDATE pets SET deleted_at = NOW(), version = version + 1 WHERE id = $1`,
+		petId,
+	)
 	if err != nil {
 		return fmt.Errorf("failed to delete pet: %v", err)
 	}
+	if err := outbox.AddEvent(ctx, tx, entity.EventPetDeleted, strconv.FormatInt(petId, 10),
+		entity.PetPresence{PetId: petId, Status: status.String}); err != nil {
+		return err
+	}
 
-	return nil
+	return tx.Commit()
+}
+
+// RestorePet brings back a soft-deleted pet.
+func (r *Repository) RestorePet(ctx context.Context, petId int64) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status sql.NullString
+	err = tx.QueryRowxContext(ctx, `
+        UPDATE pets SET deleted_at = NULL, version = version + 1
+        WHERE id = $1 AND deleted_at IS NOT NULL
+        RETURNING status`,
+		petId,
+	).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("deleted pet %d: %w", petId, entity.ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to restore pet: %v", err)
+	}
+	if err := outbox.AddEvent(ctx, tx, entity.EventPetRestored, strconv.FormatInt(petId, 10),
+		entity.PetPresence{PetId: petId, Status: status.String}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// PurgeDeletedPets removes pets soft-deleted before the given time. Pets that
+// still have orders are kept so the order history stays intact.
+func (r *Repository) PurgeDeletedPets(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `
+        DELETE FROM pets p
+        WHERE p.deleted_at < $1
+          AND NOT EXISTS (SELECT 1 FROM orders o WHERE o.petId = p.id)`,
+		before,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted pets: %v", err)
+	}
+	return res.RowsAffected()
 }
 
 func (r *Repository) GetPetById(ctx context.Context, petId int64) (petstore.Pet, error) {
+	pet, _, err := getPet(ctx, r.db, petId, false, "")
+	return pet, err
+}
+
+func (r *Repository) GetVersionedPet(ctx context.Context, petId int64, includeDeleted bool) (petstore.Pet, int64, error) {
+	return getPet(ctx, r.db, petId, includeDeleted, "")
+}
+
+// notDeleted returns the condition hiding soft-deleted pets unless includeDeleted is set.
+func notDeleted(includeDeleted bool) string {
+	if includeDeleted {
+		return ""
+	}
+	return " AND p.deleted_at IS NULL"
+}
+
+// getPet loads the pet with its category, tags and version, lock is appended to the pet
+// query (e.g. "FOR UPDATE" inside a transaction).
+func getPet(ctx context.Context, q sqlx.QueryerContext, petId int64, includeDeleted bool, lock string) (petstore.Pet, int64, error) {
 	var pet petstore.Pet
+	var version int64
 	var categoryID sql.NullInt64
 	var status sql.NullString
 	var url []string
 
-	err := r.db.QueryRow(`
-		SELECT p.id, p.name, p.photoUrls, p.category_id, p.status
+	err := q.QueryRowxContext(ctx, `
+		SELECT p.id, p.name, p.photoUrls, p.category_id, p.status, p.version
 		FROM pets p
-		WHERE p.id = $1
-	`, petId).Scan(
-		&pet.Id, &pet.Name, pq.Array(&url), &categoryID, &status,
+		WHERE p.id = $1`+notDeleted(includeDeleted)+`
+	`+lock, petId).Scan(
+		&pet.Id, &pet.Name, pq.Array(&url), &categoryID, &status, &version,
 	)
 
+	if errors.Is(err, sql.ErrNoRows) {
+		return petstore.Pet{}, 0, fmt.Errorf("pet %d: %w", petId, entity.ErrNotFound)
+	}
 	if err != nil {
-		return petstore.Pet{}, err
+		return petstore.Pet{}, 0, err
 	}
 
 	pet.PhotoUrls = url
@@ -475,11 +741,11 @@ func (r *Repository) GetPetById(ctx context.Context, petId int64) (petstore.Pet,
 		}
 		category.Id = &categoryID.Int64
 		var name sql.NullString
-		err := r.db.QueryRow(`
+		err := q.QueryRowxContext(ctx, `
 			SELECT name FROM categories WHERE id = $1
 		`, categoryID.Int64).Scan(&name)
 		if err != nil && err != sql.ErrNoRows {
-			return petstore.Pet{}, err
+			return petstore.Pet{}, 0, err
 		}
 		if name.Valid {
 			category.Name = &name.String
@@ -487,14 +753,14 @@ func (r *Repository) GetPetById(ctx context.Context, petId int64) (petstore.Pet,
 		pet.Category = &category
 	}
 
-	rows, err := r.db.Query(`
+	rows, err := q.QueryContext(ctx, `
 		SELECT t.id, t.name 
 		FROM tags t
 		JOIN pet_tags pt ON t.id = pt.tag_id
 		WHERE pt.pet_id = $1
 	`, petId)
 	if err != nil {
-		return petstore.Pet{}, err
+		return petstore.Pet{}, 0, err
 	}
 	defer rows.Close()
 
@@ -503,27 +769,59 @@ func (r *Repository) GetPetById(ctx context.Context, petId int64) (petstore.Pet,
 		var tag petstore.Tag
 		var name sql.NullString
 		if err := rows.Scan(&tag.Id, &name); err != nil {
-			return petstore.Pet{}, err
+			return petstore.Pet{}, 0, err
 		}
 		if name.Valid {
 			tag.Name = &name.String
 		}
 		tags = append(tags, tag)
 	}
+	if err := rows.Err(); err != nil {
+		return petstore.Pet{}, 0, err
+	}
 
 	if len(tags) > 0 {
 		pet.Tags = &tags
 	}
 
-	return pet, nil
+	return pet, version, nil
 }
 
+// UpdatePetWithForm sets the name and the status that are given, the others are kept.
 func (r *Repository) UpdatePetWithForm(ctx context.Context, petId int64, params petstore.UpdatePetWithFormParams) error {
-	s := petstore.PetStatus(*params.Status)
-	_, err := r.db.Exec("UPDATE pets SET name = $1, status = $2  WHERE id=$3",
-		*params.Name, s, petId)
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var oldStatus, newStatus sql.NullString
+	err = tx.QueryRowContext(ctx, `
+        UPDATE pets p
+        SET name = COALESCE($1, p.name), status = COALESCE($2, p.status), version = p.version + 1
+        FROM (SELECT id, status FROM pets WHERE id = $3 AND deleted_at IS NULL FOR UPDATE) old
+        WHERE p.id = old.id
+        RETURNING old.status, p.status`,
+		params.Name, params.Status, petId,
+	).Scan(&oldStatus, &newStatus)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("pet with id %d: %w", petId, entity.ErrNotFound)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to update pet: %w", err)
 	}
-	return nil
+
+	if err := addStatusChange(ctx, tx, petId, oldStatus.String, newStatus.String); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// addStatusChange records a PetStatusChanged event when the status differs.
+func addStatusChange(ctx context.Context, exec sqlx.ExecerContext, petId int64, from, to string) error {
+	if from == to {
+		return nil
+	}
+	return outbox.AddEvent(ctx, exec, entity.EventPetStatusChanged, strconv.FormatInt(petId, 10),
+		entity.PetStatusChange{PetId: petId, From: from, To: to})
 }
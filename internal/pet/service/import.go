@@ -0,0 +1,252 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"swagger_petstore/entity"
+	auditService "swagger_petstore/internal/audit/service"
+	"swagger_petstore/petstore"
+)
+
+const (
+	ImportCSV    = "csv"
+	ImportNDJSON = "ndjson"
+
+	importBatchSize = 500
+	// importListSeparator separates tags and photo URLs inside one CSV cell.
+	importListSeparator = "|"
+)
+
+var importColumns = map[string]string{
+	"name":       "name",
+	"status":     "status",
+	"category":   "category",
+	"tags":       "tags",
+	"photourls":  "photoUrls",
+	"photo_urls": "photoUrls",
+}
+
+// importRecord is one pet of an import file.
+type importRecord struct {
+	Name      string   `json:"name"`
+	Status    string   `json:"status"`
+	Category  string   `json:"category"`
+	Tags      []string `json:"tags"`
+	PhotoUrls []string `json:"photoUrls"`
+}
+
+// ImportPets reads pets from a CSV file with a header row or from JSON Lines, validates
+// every row and inserts the valid ones in batches. The report lists the outcome of every row.
+func (s *Service) ImportPets(ctx context.Context, r io.Reader, format string, dryRun bool) (entity.ImportReport, error) {
+	report := entity.ImportReport{DryRun: dryRun, Rows: []entity.ImportResult{}}
+
+	var batch []petstore.Pet
+	var rows []int
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		results, err := s.repository.ImportPets(ctx, batch, dryRun)
+		if err != nil {
+			return fmt.Errorf("failed to import pets: %w", err)
+		}
+		for i, result := range results {
+			result.Row = rows[i]
+			addResult(&report, result)
+			if result.Status == entity.ImportCreated {
+				created := batch[i]
+				created.Id = result.Id
+				s.record(ctx, *result.Id, auditService.ActionCreate, nil, &created)
+			}
+		}
+		batch, rows = batch[:0], rows[:0]
+		return nil
+	}
+
+	err := readImport(r, format, func(row int, record importRecord, err error) error {
+		var pet petstore.Pet
+		if err == nil {
+			pet, err = record.pet()
+		}
+		if err != nil {
+			addResult(&report, entity.ImportResult{Row: row, Name: record.Name, Status: entity.ImportFailed, Error: err.Error()})
+			return nil
+		}
+
+		batch = append(batch, pet)
+		rows = append(rows, row)
+		if len(batch) == importBatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return entity.ImportReport{}, err
+	}
+	if err := flush(); err != nil {
+		return entity.ImportReport{}, err
+	}
+
+	return report, nil
+}
+
+func addResult(report *entity.ImportReport, result entity.ImportResult) {
+	switch result.Status {
+	case entity.ImportCreated:
+		report.Created++
+	case entity.ImportValid:
+		report.Valid++
+	default:
+		report.Failed++
+	}
+	report.Rows = append(report.Rows, result)
+}
+
+// readImport calls handle for every row of the file. Rows that cannot be decoded are passed
+// with an error, an error returned by handle or an unreadable file stops the import.
+func readImport(r io.Reader, format string, handle func(row int, record importRecord, err error) error) error {
+	switch format {
+	case ImportCSV:
+		return readCSV(r, handle)
+	case ImportNDJSON:
+		return readNDJSON(r, handle)
+	}
+	return fmt.Errorf("%w: unsupported import format %q", entity.ErrInvalid, format)
+}
+
+func readCSV(r io.Reader, handle func(row int, record importRecord, err error) error) error {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return fmt.Errorf("%w: empty import file", entity.ErrInvalid)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: failed to read header: %v", entity.ErrInvalid, err)
+	}
+
+	columns := make([]string, len(header))
+	hasName := false
+	for i, name := range header {
+		column, ok := importColumns[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return fmt.Errorf("%w: unknown column %q", entity.ErrInvalid, name)
+		}
+		columns[i] = column
+		hasName = hasName || column == "name"
+	}
+	if !hasName {
+		return fmt.Errorf("%w: name column required", entity.ErrInvalid)
+	}
+
+	for row := 1; ; row++ {
+		fields, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		var parseErr *csv.ParseError
+		if err != nil && !errors.As(err, &parseErr) {
+			return fmt.Errorf("%w: failed to read row %d: %v", entity.ErrInvalid, row, err)
+		}
+
+		var record importRecord
+		if err == nil {
+			for i, value := range fields {
+				value = strings.TrimSpace(value)
+				switch columns[i] {
+				case "name":
+					record.Name = value
+				case "status":
+					record.Status = value
+				case "category":
+					record.Category = value
+				case "tags":
+					record.Tags = splitList(value)
+				case "photoUrls":
+					record.PhotoUrls = splitList(value)
+				}
+			}
+		}
+		if err := handle(row, record, err); err != nil {
+			return err
+		}
+	}
+}
+
+func readNDJSON(r io.Reader, handle func(row int, record importRecord, err error) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	row := 0
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		row++
+
+		var record importRecord
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.DisallowUnknownFields()
+		err := decoder.Decode(&record)
+		if err != nil {
+			err = fmt.Errorf("invalid JSON: %v", err)
+		}
+		if err := handle(row, record, err); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%w: failed to read line %d: %v", entity.ErrInvalid, row+1, err)
+	}
+	return nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, importListSeparator) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// pet converts the record and validates it like a pet created through the API.
+func (record importRecord) pet() (petstore.Pet, error) {
+	pet := petstore.Pet{
+		Name:      record.Name,
+		PhotoUrls: record.PhotoUrls,
+	}
+	if pet.PhotoUrls == nil {
+		pet.PhotoUrls = []string{}
+	}
+	if record.Status != "" {
+		status := petstore.PetStatus(record.Status)
+		pet.Status = &status
+	}
+	if record.Category != "" {
+		category := record.Category
+		pet.Category = &petstore.Category{Name: &category}
+	}
+	if len(record.Tags) > 0 {
+		tags := make([]petstore.Tag, len(record.Tags))
+		for i := range record.Tags {
+			tags[i].Name = &record.Tags[i]
+		}
+		pet.Tags = &tags
+	}
+
+	if err := validatePet(pet); err != nil {
+		return petstore.Pet{}, err
+	}
+	return pet, nil
+}
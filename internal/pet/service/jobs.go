@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	ImportJob  = "pet_import"
+	ReindexJob = "pet_search_reindex"
+
+	reindexBatchSize = 1000
+)
+
+// ImportPayload is the payload of an ImportJob.
+type ImportPayload struct {
+	Format string `json:"format"`
+	DryRun bool   `json:"dryRun"`
+	Data   string `json:"data"`
+}
+
+// RunImportJob imports the file of an ImportJob, the result is the import report.
+func (s *Service) RunImportJob(ctx context.Context, payload json.RawMessage, progress func(percent int)) (interface{}, error) {
+	var job ImportPayload
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return nil, fmt.Errorf("invalid import payload: %w", err)
+	}
+	return s.ImportPets(ctx, strings.NewReader(job.Data), job.Format, job.DryRun)
+}
+
+// RunReindexJob rebuilds the search document of every pet in batches.
+func (s *Service) RunReindexJob(ctx context.Context, payload json.RawMessage, progress func(percent int)) (interface{}, error) {
+	total, err := s.repository.CountPets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastId, done int64
+	for {
+		id, count, err := s.repository.ReindexSearch(ctx, lastId, reindexBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			break
+		}
+		lastId = id
+		done += int64(count)
+		if total > 0 {
+			progress(int(done * 100 / total))
+		}
+	}
+
+	return map[string]int64{"reindexed": done}, nil
+}
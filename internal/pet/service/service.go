@@ -3,67 +3,245 @@ package service
 import (
 	"context"
 	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"swagger_petstore/entity"
+	auditService "swagger_petstore/internal/audit/service"
 	"swagger_petstore/internal/pet/repository"
+	"swagger_petstore/mergepatch"
 	"swagger_petstore/petstore"
+	"time"
+)
+
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 200
 )
 
 type Servicer interface {
 	AddPet(ctx context.Context, pet petstore.Pet) error
-	UpdatePet(ctx context.Context, pet petstore.Pet) error
-	FindPetsByStatus(ctx context.Context, status petstore.FindPetsByStatusParams) ([]petstore.Pet, error)
-	FindPetsByTags(ctx context.Context, status petstore.FindPetsByTagsParams) ([]petstore.Pet, error)
-	DeletePet(ctx context.Context, petId int64, params petstore.DeletePetParams) error
+	ImportPets(ctx context.Context, r io.Reader, format string, dryRun bool) (entity.ImportReport, error)
+	UpdatePet(ctx context.Context, pet petstore.Pet, version int64) error
+	FindPetsByStatus(ctx context.Context, status petstore.FindPetsByStatusParams, includeDeleted bool) ([]petstore.Pet, error)
+	FindPetsByTags(ctx context.Context, tags entity.TagQuery, includeDeleted bool) ([]petstore.Pet, error)
+	DeletePet(ctx context.Context, petId int64, params petstore.DeletePetParams, version int64) error
 	GetPetById(ctx context.Context, petId int64) (petstore.Pet, error)
+	GetVersionedPet(ctx context.Context, petId int64, includeDeleted bool) (petstore.Pet, int64, error)
+	RestorePet(ctx context.Context, petId int64) error
+	PurgeDeletedPets(ctx context.Context, retention time.Duration) (int64, error)
 	UpdatePetWithForm(ctx context.Context, petId int64, params petstore.UpdatePetWithFormParams) error
+	PatchPet(ctx context.Context, petId int64, version int64, patch []byte) (petstore.Pet, int64, error)
+	SearchPets(ctx context.Context, search entity.PetSearch) ([]petstore.Pet, error)
+	ExportPets(ctx context.Context, search entity.PetSearch, each func(pet entity.PetRecord) error) error
 }
 type Service struct {
 	repository repository.PetsRepository
+	audit      auditService.Recorder
 }
 
-func PetService(repository repository.PetsRepository) *Service {
-	return &Service{repository: repository}
+func PetService(repository repository.PetsRepository, audit auditService.Recorder) *Service {
+	return &Service{repository: repository, audit: audit}
 }
 
 func (s *Service) AddPet(ctx context.Context, pet petstore.Pet) error {
-	return s.repository.AddPet(ctx, pet)
+	petId, err := s.repository.AddPet(ctx, pet)
+	if err != nil {
+		return err
+	}
+	s.record(ctx, petId, auditService.ActionCreate, nil, s.snapshot(ctx, petId))
+	return nil
 }
-func (s *Service) UpdatePet(ctx context.Context, pet petstore.Pet) error {
-	return s.repository.UpdatePet(ctx, pet)
+func (s *Service) UpdatePet(ctx context.Context, pet petstore.Pet, version int64) error {
+	if pet.Id == nil {
+		return s.repository.UpdatePet(ctx, pet, version)
+	}
+	before := s.snapshot(ctx, *pet.Id)
+	if err := s.repository.UpdatePet(ctx, pet, version); err != nil {
+		return err
+	}
+	s.record(ctx, *pet.Id, auditService.ActionUpdate, before, s.snapshot(ctx, *pet.Id))
+	return nil
 }
 
-func (s *Service) FindPetsByStatus(ctx context.Context, status petstore.FindPetsByStatusParams) ([]petstore.Pet, error) {
+func (s *Service) FindPetsByStatus(ctx context.Context, status petstore.FindPetsByStatusParams, includeDeleted bool) ([]petstore.Pet, error) {
 	if status.Status == nil {
-		return nil, fmt.Errorf("status required")
+		return nil, fmt.Errorf("%w: status required", entity.ErrInvalid)
 	}
-	return s.repository.FindPetsByStatus(ctx, status)
+	return s.repository.FindPetsByStatus(ctx, status, includeDeleted)
 }
 
-func (s *Service) FindPetsByTags(ctx context.Context, status petstore.FindPetsByTagsParams) ([]petstore.Pet, error) {
-	if status.Tags == nil {
-		return nil, fmt.Errorf("tags required")
+func (s *Service) FindPetsByTags(ctx context.Context, tags entity.TagQuery, includeDeleted bool) ([]petstore.Pet, error) {
+	tags.Tags = normalizeTags(tags.Tags)
+	tags.Exclude = normalizeTags(tags.Exclude)
+	if len(tags.Tags) == 0 && len(tags.Exclude) == 0 {
+		return nil, fmt.Errorf("%w: tags or exclude required", entity.ErrInvalid)
+	}
+	switch tags.Match {
+	case "":
+		tags.Match = entity.TagMatchAll
+	case entity.TagMatchAll, entity.TagMatchAny:
+	default:
+		return nil, fmt.Errorf("%w: match must be one of all, any", entity.ErrInvalid)
 	}
-	return s.repository.FindPetsByTags(ctx, status)
+	return s.repository.FindPetsByTags(ctx, tags, includeDeleted)
 }
 
-func (s *Service) DeletePet(ctx context.Context, petId int64, params petstore.DeletePetParams) error {
+// normalizeTags lower-cases the tag names and drops blanks and duplicates.
+func normalizeTags(tags []string) []string {
+	var normalized []string
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+func (s *Service) SearchPets(ctx context.Context, search entity.PetSearch) ([]petstore.Pet, error) {
+	search.Query = strings.TrimSpace(search.Query)
+	if search.Query == "" {
+		return nil, fmt.Errorf("%w: q required", entity.ErrInvalid)
+	}
+	search.Tags = normalizeTags(search.Tags)
+	if search.Status != "" && !validStatus(petstore.PetStatus(search.Status)) {
+		return nil, fmt.Errorf("%w: invalid status %q", entity.ErrInvalid, search.Status)
+	}
+	if search.Limit <= 0 {
+		search.Limit = defaultSearchLimit
+	}
+	if search.Limit > maxSearchLimit {
+		return nil, fmt.Errorf("%w: limit must not exceed %d", entity.ErrInvalid, maxSearchLimit)
+	}
+	if search.Offset < 0 {
+		return nil, fmt.Errorf("%w: offset must not be negative", entity.ErrInvalid)
+	}
+	return s.repository.SearchPets(ctx, search)
+}
+
+func (s *Service) ExportPets(ctx context.Context, search entity.PetSearch, each func(pet entity.PetRecord) error) error {
+	search.Query = strings.TrimSpace(search.Query)
+	search.Tags = normalizeTags(search.Tags)
+	if search.Status != "" && !validStatus(petstore.PetStatus(search.Status)) {
+		return fmt.Errorf("%w: invalid status %q", entity.ErrInvalid, search.Status)
+	}
+	return s.repository.ExportPets(ctx, search, each)
+}
+
+func (s *Service) DeletePet(ctx context.Context, petId int64, params petstore.DeletePetParams, version int64) error {
 	if petId <= 0 {
-		return fmt.Errorf("invalid petId: must be a positive number")
+		return fmt.Errorf("%w: invalid petId: must be a positive number", entity.ErrInvalid)
 	}
-	return s.repository.DeletePet(ctx, petId, params)
+	before := s.snapshot(ctx, petId)
+	if err := s.repository.DeletePet(ctx, petId, params, version); err != nil {
+		return err
+	}
+	s.record(ctx, petId, auditService.ActionDelete, before, nil)
+	return nil
 }
 func (s *Service) GetPetById(ctx context.Context, petId int64) (petstore.Pet, error) {
 	if petId <= 0 {
-		return petstore.Pet{}, fmt.Errorf("invalid petId: must be a positive number")
+		return petstore.Pet{}, fmt.Errorf("%w: invalid petId: must be a positive number", entity.ErrInvalid)
 	}
 	return s.repository.GetPetById(ctx, petId)
 }
 
+func (s *Service) GetVersionedPet(ctx context.Context, petId int64, includeDeleted bool) (petstore.Pet, int64, error) {
+	if petId <= 0 {
+		return petstore.Pet{}, 0, fmt.Errorf("%w: invalid petId: must be a positive number", entity.ErrInvalid)
+	}
+	return s.repository.GetVersionedPet(ctx, petId, includeDeleted)
+}
+
+func (s *Service) RestorePet(ctx context.Context, petId int64) error {
+	if petId <= 0 {
+		return fmt.Errorf("%w: invalid petId: must be a positive number", entity.ErrInvalid)
+	}
+	if err := s.repository.RestorePet(ctx, petId); err != nil {
+		return err
+	}
+	s.record(ctx, petId, auditService.ActionRestore, nil, s.snapshot(ctx, petId))
+	return nil
+}
+
+// PurgeDeletedPets permanently removes pets that were soft-deleted longer than retention ago.
+func (s *Service) PurgeDeletedPets(ctx context.Context, retention time.Duration) (int64, error) {
+	return s.repository.PurgeDeletedPets(ctx, time.Now().Add(-retention))
+}
+
 func (s *Service) UpdatePetWithForm(ctx context.Context, petId int64, params petstore.UpdatePetWithFormParams) error {
 	if petId <= 0 {
-		return fmt.Errorf("invalid petId: must be a positive number")
+		return fmt.Errorf("%w: invalid petId: must be a positive number", entity.ErrInvalid)
 	}
 	if params.Name == nil && params.Status == nil {
-		return fmt.Errorf("name or status required")
+		return fmt.Errorf("%w: name or status required", entity.ErrInvalid)
+	}
+	if params.Status != nil && !validStatus(petstore.PetStatus(*params.Status)) {
+		return fmt.Errorf("%w: invalid status %q", entity.ErrInvalid, *params.Status)
+	}
+	before := s.snapshot(ctx, petId)
+	if err := s.repository.UpdatePetWithForm(ctx, petId, params); err != nil {
+		return err
+	}
+	s.record(ctx, petId, auditService.ActionUpdate, before, s.snapshot(ctx, petId))
+	return nil
+}
+
+func (s *Service) PatchPet(ctx context.Context, petId int64, version int64, patch []byte) (petstore.Pet, int64, error) {
+	if petId <= 0 {
+		return petstore.Pet{}, 0, fmt.Errorf("%w: invalid petId: must be a positive number", entity.ErrInvalid)
+	}
+	var before petstore.Pet
+	pet, newVersion, err := s.repository.PatchPet(ctx, petId, version, func(pet *petstore.Pet) error {
+		before = *pet
+		var patched petstore.Pet
+		if err := mergepatch.Apply(pet, patch, &patched); err != nil {
+			return fmt.Errorf("%w: %v", entity.ErrInvalid, err)
+		}
+		if err := validatePet(patched); err != nil {
+			return fmt.Errorf("%w: %v", entity.ErrInvalid, err)
+		}
+		*pet = patched
+		return nil
+	})
+	if err != nil {
+		return petstore.Pet{}, 0, err
+	}
+	s.record(ctx, petId, auditService.ActionUpdate, before, pet)
+	return pet, newVersion, nil
+}
+
+// snapshot returns the current state of the pet for the audit trail, nil if it cannot be read.
+func (s *Service) snapshot(ctx context.Context, petId int64) *petstore.Pet {
+	pet, _, err := s.repository.GetVersionedPet(ctx, petId, true)
+	if err != nil {
+		return nil
+	}
+	return &pet
+}
+
+func (s *Service) record(ctx context.Context, petId int64, action string, before, after interface{}) {
+	s.audit.Record(ctx, auditService.EntityPet, strconv.FormatInt(petId, 10), action, before, after)
+}
+
+func validatePet(pet petstore.Pet) error {
+	if pet.Name == "" {
+		return fmt.Errorf("name required")
+	}
+	if pet.Status != nil && !validStatus(*pet.Status) {
+		return fmt.Errorf("invalid status %q", *pet.Status)
+	}
+	return nil
+}
+
+func validStatus(status petstore.PetStatus) bool {
+	switch status {
+	case petstore.PetStatusAvailable, petstore.PetStatusPending, petstore.PetStatusSold:
+		return true
 	}
-	return s.repository.UpdatePetWithForm(ctx, petId, params)
+	return false
 }
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"io"
+	"swagger_petstore/entity"
+	"swagger_petstore/petstore"
+	"swagger_petstore/tracing"
+	"time"
+)
+
+// tracedService starts a span around the methods of the service that can fail.
+type tracedService struct {
+	Servicer
+}
+
+// Traced wraps the service so that its calls are traced.
+func Traced(service Servicer) Servicer {
+	return tracedService{Servicer: service}
+}
+
+func (s tracedService) AddPet(ctx context.Context, pet petstore.Pet) (err error) {
+	ctx, span := tracing.Start(ctx, "PetService.AddPet")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.AddPet(ctx, pet)
+}
+
+func (s tracedService) ImportPets(ctx context.Context, r io.Reader, format string, dryRun bool) (_ entity.ImportReport, err error) {
+	ctx, span := tracing.Start(ctx, "PetService.ImportPets")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.ImportPets(ctx, r, format, dryRun)
+}
+
+func (s tracedService) UpdatePet(ctx context.Context, pet petstore.Pet, version int64) (err error) {
+	ctx, span := tracing.Start(ctx, "PetService.UpdatePet")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.UpdatePet(ctx, pet, version)
+}
+
+func (s tracedService) FindPetsByStatus(ctx context.Context, status petstore.FindPetsByStatusParams, includeDeleted bool) (_ []petstore.Pet, err error) {
+	ctx, span := tracing.Start(ctx, "PetService.FindPetsByStatus")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.FindPetsByStatus(ctx, status, includeDeleted)
+}
+
+func (s tracedService) FindPetsByTags(ctx context.Context, tags entity.TagQuery, includeDeleted bool) (_ []petstore.Pet, err error) {
+	ctx, span := tracing.Start(ctx, "PetService.FindPetsByTags")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.FindPetsByTags(ctx, tags, includeDeleted)
+}
+
+func (s tracedService) DeletePet(ctx context.Context, petId int64, params petstore.DeletePetParams, version int64) (err error) {
+	ctx, span := tracing.Start(ctx, "PetService.DeletePet")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.DeletePet(ctx, petId, params, version)
+}
+
+func (s tracedService) GetPetById(ctx context.Context, petId int64) (_ petstore.Pet, err error) {
+	ctx, span := tracing.Start(ctx, "PetService.GetPetById")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.GetPetById(ctx, petId)
+}
+
+func (s tracedService) GetVersionedPet(ctx context.Context, petId int64, includeDeleted bool) (_ petstore.Pet, _ int64, err error) {
+	ctx, span := tracing.Start(ctx, "PetService.GetVersionedPet")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.GetVersionedPet(ctx, petId, includeDeleted)
+}
+
+func (s tracedService) RestorePet(ctx context.Context, petId int64) (err error) {
+	ctx, span := tracing.Start(ctx, "PetService.RestorePet")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.RestorePet(ctx, petId)
+}
+
+func (s tracedService) PurgeDeletedPets(ctx context.Context, retention time.Duration) (_ int64, err error) {
+	ctx, span := tracing.Start(ctx, "PetService.PurgeDeletedPets")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.PurgeDeletedPets(ctx, retention)
+}
+
+func (s tracedService) UpdatePetWithForm(ctx context.Context, petId int64, params petstore.UpdatePetWithFormParams) (err error) {
+	ctx, span := tracing.Start(ctx, "PetService.UpdatePetWithForm")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.UpdatePetWithForm(ctx, petId, params)
+}
+
+func (s tracedService) PatchPet(ctx context.Context, petId int64, version int64, patch []byte) (_ petstore.Pet, _ int64, err error) {
+	ctx, span := tracing.Start(ctx, "PetService.PatchPet")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.PatchPet(ctx, petId, version, patch)
+}
+
+func (s tracedService) SearchPets(ctx context.Context, search entity.PetSearch) (_ []petstore.Pet, err error) {
+	ctx, span := tracing.Start(ctx, "PetService.SearchPets")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.SearchPets(ctx, search)
+}
+
+func (s tracedService) ExportPets(ctx context.Context, search entity.PetSearch, each func(pet entity.PetRecord) error) (err error) {
+	ctx, span := tracing.Start(ctx, "PetService.ExportPets")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.ExportPets(ctx, search, each)
+}
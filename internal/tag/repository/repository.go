@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"swagger_petstore/entity"
+	"swagger_petstore/postgres"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type TagsRepository interface {
+	GetTags(ctx context.Context) ([]entity.TagUsage, error)
+	GetTagById(ctx context.Context, tagId int64) (entity.TagUsage, error)
+	RenameTag(ctx context.Context, tagId int64, name string) error
+	MergeTags(ctx context.Context, sourceId, targetId int64) error
+	DeleteTag(ctx context.Context, tagId int64) error
+	DeleteUnusedTags(ctx context.Context) (int64, error)
+}
+type Repository struct {
+	db *sqlx.DB
+}
+
+func NewTagRepository(db *sqlx.DB) TagsRepository {
+	return &Repository{db: db}
+}
+
+const tagUsageQuery = `
+	SELECT t.id, t.name, COUNT(p.id) AS pets
+	FROM tags t
+	LEFT JOIN pet_tags pt ON pt.tag_id = t.id
+	LEFT JOIN pets p ON p.id = pt.pet_id AND p.deleted_at IS NULL`
+
+func (r *Repository) GetTags(ctx context.Context) ([]entity.TagUsage, error) {
+	tags := []entity.TagUsage{}
+	query := tagUsageQuery + `
+	GROUP BY t.id, t.name
+	ORDER BY t.name`
+	err := r.db.SelectContext(ctx, &tags, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+	return tags, nil
+}
+
+func (r *Repository) GetTagById(ctx context.Context, tagId int64) (entity.TagUsage, error) {
+	var tag entity.TagUsage
+	query := tagUsageQuery + `
+	WHERE t.id = $1
+	GROUP BY t.id, t.name`
+	err := r.db.GetContext(ctx, &tag, query, tagId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.TagUsage{}, fmt.Errorf("tag %d: %w", tagId, entity.ErrNotFound)
+	}
+	if err != nil {
+		return entity.TagUsage{}, fmt.Errorf("failed to get tag by ID: %w", err)
+	}
+	return tag, nil
+}
+
+func (r *Repository) RenameTag(ctx context.Context, tagId int64, name string) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE tags SET name = $1 WHERE id = $2", name, tagId)
+	if postgres.IsUniqueViolation(err) {
+		return fmt.Errorf("tag %q: %w", name, entity.ErrConflict)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to rename tag: %w", err)
+	}
+	return expectAffected(res, tagId)
+}
+
+// MergeTags moves every pet of the source tag to the target tag and removes the source.
+func (r *Repository) MergeTags(ctx context.Context, sourceId, targetId int64) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var found int
+	err = tx.GetContext(ctx, &found, "SELECT COUNT(*) FROM tags WHERE id IN ($1, $2)", sourceId, targetId)
+	if err != nil {
+		return fmt.Errorf("failed to check tags: %w", err)
+	}
+	if found != 2 {
+		return fmt.Errorf("tags %d, %d: %w", sourceId, targetId, entity.ErrNotFound)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO pet_tags (pet_id, tag_id)
+		SELECT pet_id, $2 FROM pet_tags WHERE tag_id = $1
+		ON CONFLICT DO NOTHING`,
+		sourceId, targetId,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to move pet tags: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM tags WHERE id = $1", sourceId)
+	if err != nil {
+		return fmt.Errorf("failed to delete merged tag: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// DeleteTag removes a tag that is not used by any pet.
+func (r *Repository) DeleteTag(ctx context.Context, tagId int64) error {
+	res, err := r.db.ExecContext(ctx, `
+		DELETE FROM tags t
+		WHERE t.id = $1 AND NOT EXISTS (SELECT 1 FROM pet_tags pt WHERE pt.tag_id = t.id)`,
+		tagId,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		if _, err := r.GetTagById(ctx, tagId); err != nil {
+			return err
+		}
+		return fmt.Errorf("tag %d is still used by pets: %w", tagId, entity.ErrConflict)
+	}
+	return nil
+}
+
+func (r *Repository) DeleteUnusedTags(ctx context.Context) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `
+		DELETE FROM tags t
+		WHERE NOT EXISTS (SELECT 1 FROM pet_tags pt WHERE pt.tag_id = t.id)`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete unused tags: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+func expectAffected(res sql.Result, tagId int64) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("tag %d: %w", tagId, entity.ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"swagger_petstore/entity"
+	"swagger_petstore/internal/tag/repository"
+	"swagger_petstore/petstore"
+)
+
+type Servicer interface {
+	GetTags(ctx context.Context) ([]entity.TagUsage, error)
+	RenameTag(ctx context.Context, tagId int64, tag petstore.Tag) error
+	MergeTags(ctx context.Context, sourceId, targetId int64) error
+	DeleteTag(ctx context.Context, tagId int64) error
+	DeleteUnusedTags(ctx context.Context) (int64, error)
+}
+type TagService struct {
+	repository repository.TagsRepository
+}
+
+func NewTagService(repository repository.TagsRepository) *TagService {
+	return &TagService{repository: repository}
+}
+
+func (s *TagService) GetTags(ctx context.Context) ([]entity.TagUsage, error) {
+	return s.repository.GetTags(ctx)
+}
+
+func (s *TagService) RenameTag(ctx context.Context, tagId int64, tag petstore.Tag) error {
+	if tagId <= 0 {
+		return fmt.Errorf("%w: invalid tagId: must be a positive number", entity.ErrInvalid)
+	}
+	if tag.Name == nil || strings.TrimSpace(*tag.Name) == "" {
+		return fmt.Errorf("%w: tag name required", entity.ErrInvalid)
+	}
+	return s.repository.RenameTag(ctx, tagId, strings.TrimSpace(*tag.Name))
+}
+
+func (s *TagService) MergeTags(ctx context.Context, sourceId, targetId int64) error {
+	if sourceId <= 0 || targetId <= 0 {
+		return fmt.Errorf("%w: invalid tagId: must be a positive number", entity.ErrInvalid)
+	}
+	if sourceId == targetId {
+		return fmt.Errorf("%w: cannot merge a tag into itself", entity.ErrInvalid)
+	}
+	return s.repository.MergeTags(ctx, sourceId, targetId)
+}
+
+func (s *TagService) DeleteTag(ctx context.Context, tagId int64) error {
+	if tagId <= 0 {
+		return fmt.Errorf("%w: invalid tagId: must be a positive number", entity.ErrInvalid)
+	}
+	return s.repository.DeleteTag(ctx, tagId)
+}
+
+func (s *TagService) DeleteUnusedTags(ctx context.Context) (int64, error) {
+	return s.repository.DeleteUnusedTags(ctx)
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"swagger_petstore/entity"
+	"swagger_petstore/petstore"
+	"swagger_petstore/tracing"
+)
+
+// tracedService starts a span around the methods of the service that can fail.
+type tracedService struct {
+	Servicer
+}
+
+// Traced wraps the service so that its calls are traced.
+func Traced(service Servicer) Servicer {
+	return tracedService{Servicer: service}
+}
+
+func (s tracedService) GetTags(ctx context.Context) (_ []entity.TagUsage, err error) {
+	ctx, span := tracing.Start(ctx, "TagService.GetTags")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.GetTags(ctx)
+}
+
+func (s tracedService) RenameTag(ctx context.Context, tagId int64, tag petstore.Tag) (err error) {
+	ctx, span := tracing.Start(ctx, "TagService.RenameTag")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.RenameTag(ctx, tagId, tag)
+}
+
+func (s tracedService) MergeTags(ctx context.Context, sourceId, targetId int64) (err error) {
+	ctx, span := tracing.Start(ctx, "TagService.MergeTags")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.MergeTags(ctx, sourceId, targetId)
+}
+
+func (s tracedService) DeleteTag(ctx context.Context, tagId int64) (err error) {
+	ctx, span := tracing.Start(ctx, "TagService.DeleteTag")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.DeleteTag(ctx, tagId)
+}
+
+func (s tracedService) DeleteUnusedTags(ctx context.Context) (_ int64, err error) {
+	ctx, span := tracing.Start(ctx, "TagService.DeleteUnusedTags")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.DeleteUnusedTags(ctx)
+}
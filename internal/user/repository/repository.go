@@ -2,11 +2,17 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"swagger_petstore/entity"
+	outbox "swagger_petstore/internal/outbox/repository"
 	"swagger_petstore/petstore"
+	"swagger_petstore/postgres"
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type UsersRepository interface {
@@ -14,49 +20,150 @@ type UsersRepository interface {
 	CreateUser(ctx context.Context, user petstore.User) error
 	LoginUser(ctx context.Context, params petstore.LoginUserParams) (petstore.User, error)
 	LogoutUser(ctx context.Context, tokenID string, token string, exp time.Time) error
-	DeleteUser(ctx context.Context, username string) error
+	DeleteUser(ctx context.Context, username string, version int64) error
 	GetUserByName(ctx context.Context, username string) (petstore.User, error)
-	UpdateUser(ctx context.Context, user petstore.User) error
+	GetVersionedUser(ctx context.Context, username string, includeDeleted bool) (petstore.User, int64, error)
+	RestoreUser(ctx context.Context, username string) error
+	PurgeDeletedUsers(ctx context.Context, before time.Time) (int64, error)
+	UpdateUser(ctx context.Context, user petstore.User, version int64) error
+	ExportUsers(ctx context.Context, filter entity.UserFilter, each func(user entity.UserRecord) error) error
+	GetLoginFailures(ctx context.Context, keys []string) ([]entity.LoginFailures, error)
+	AddLoginFailure(ctx context.Context, key string, now, since time.Time) (entity.LoginFailures, error)
+	LockLogin(ctx context.Context, key string, until time.Time) error
+	ResetLoginFailures(ctx context.Context, key string) (bool, error)
+	PurgeLoginFailures(ctx context.Context, before time.Time) (int64, error)
+	GetUserRole(ctx context.Context, username string) (entity.Role, error)
+	SetUserRole(ctx context.Context, username string, role entity.Role) (entity.Role, error)
 }
 type UserRepository struct {
 	db *sqlx.DB
 }
 
+// userColumns are read back without the password, which is only ever compared in the database.
+const userColumns = "id, username, firstName, lastName, email, phone, userStatus"
+
+type versionedUser struct {
+	petstore.User
+	Version int64 `db:"version"`
+}
+
 func NewUserRepository(db *sqlx.DB) UsersRepository {
 	return &UserRepository{db: db}
 }
 
 func (r *UserRepository) CreateUser(ctx context.Context, user petstore.User) error {
-	_, err := r.db.ExecContext(ctx, "INSERT INTO users (id, username, firstName, lastName, password, email, phone, userStatus) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
-		user.Id, user.Username, user.FirstName, user.LastName, user.Password, user.Email, user.Phone, user.UserStatus)
-	if err != nil {
-		return fmt.Errorf("failed to create user: %w", err)
-	}
-	return nil
+	return r.CreateUsersWithListInput(ctx, []petstore.User{user})
 }
 
+// CreateUsersWithListInput creates all users or none of them.
 func (r *UserRepository) CreateUsersWithListInput(ctx context.Context, users []petstore.User) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	for _, user := range users {
-		err := r.CreateUser(ctx, user)
+		_, err := tx.ExecContext(ctx, "INSERT INTO users (id, username, firstName, lastName, password, email, phone, userStatus) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+			user.Id, user.Username, user.FirstName, user.LastName, user.Password, user.Email, user.Phone, user.UserStatus)
 		if err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+
+		var registration entity.UserRegistration
+		if user.Username != nil {
+			registration.Username = *user.Username
+		}
+		if user.Email != nil {
+			registration.Email = *user.Email
+		}
+		if err := outbox.AddEvent(ctx, tx, entity.EventUserRegistered, registration.Username, registration); err != nil {
 			return err
 		}
 	}
-	return nil
+	return tx.Commit()
 }
 
 func (r *UserRepository) LoginUser(ctx context.Context, params petstore.LoginUserParams) (petstore.User, error) {
 	var user petstore.User
-	query := `SELECT *
+	query := `SELECT ` + userColumns + `
 			 FROM users 
-			 WHERE username = $1 and password = $2`
+			 WHERE username = $1 and password = $2 AND deleted_at IS NULL`
 	err := r.db.GetContext(ctx, &user, query, params.Username, params.Password)
+	if errors.Is(err, sql.ErrNoRows) {
+		return petstore.User{}, fmt.Errorf("user: %w", entity.ErrNotFound)
+	}
 	if err != nil {
-		return petstore.User{}, fmt.Errorf("user was not found: %w", err)
+		return petstore.User{}, fmt.Errorf("failed to log in user: %w", err)
 	}
 	return user, nil
 }
 
+const loginFailureColumns = "key, failures, last_failed_at, locked_until"
+
+func (r *UserRepository) GetLoginFailures(ctx context.Context, keys []string) ([]entity.LoginFailures, error) {
+	failures := []entity.LoginFailures{}
+	err := r.db.SelectContext(ctx, &failures,
+		`SELECT `+loginFailureColumns+` FROM login_failures WHERE key = ANY($1)`, pq.Array(keys))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get login failures: %w", err)
+	}
+	return failures, nil
+}
+
+// AddLoginFailure counts a failed login at now, the count starts over when the previous
+// failure is older than since.
+func (r *UserRepository) AddLoginFailure(ctx context.Context, key string, now, since time.Time) (entity.LoginFailures, error) {
+	var failures entity.LoginFailures
+	err := r.db.GetContext(ctx, &failures, `
+		INSERT INTO login_failures (key, failures, last_failed_at)
+		VALUES ($1, 1, $2)
+		ON CONFLICT (key) DO UPDATE
+		SET failures = CASE WHEN login_failures.last_failed_at < $3 THEN 1 ELSE login_failures.failures + 1 END,
+			last_failed_at = EXCLUDED.last_failed_at
+		RETURNING `+loginFailureColumns,
+		key, now, since,
+	)
+	if err != nil {
+		return entity.LoginFailures{}, fmt.Errorf("failed to add login failure: %w", err)
+	}
+	return failures, nil
+}
+
+// LockLogin locks the key until the given time and starts its count over.
+func (r *UserRepository) LockLogin(ctx context.Context, key string, until time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE login_failures SET locked_until = $2, failures = 0 WHERE key = $1`, key, until)
+	if err != nil {
+		return fmt.Errorf("failed to lock login: %w", err)
+	}
+	return nil
+}
+
+// ResetLoginFailures forgets the failures and the lock of the key and reports whether
+// there were any.
+func (r *UserRepository) ResetLoginFailures(ctx context.Context, key string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM login_failures WHERE key = $1`, key)
+	if err != nil {
+		return false, fmt.Errorf("failed to reset login failures: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// PurgeLoginFailures deletes the failures last seen before the given time whose lock is over.
+func (r *UserRepository) PurgeLoginFailures(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `
+		DELETE FROM login_failures
+		WHERE last_failed_at < $1 AND (locked_until IS NULL OR locked_until < $1)`,
+		before,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge login failures: %w", err)
+	}
+	return res.RowsAffected()
+}
+
 func (r *UserRepository) LogoutUser(ctx context.Context, tokenID string, token string, exp time.Time) error {
 	_, err := r.db.ExecContext(ctx, `
 		INSERT INTO token_blacklist (token_id, token, expires_at)
@@ -67,30 +174,173 @@ func (r *UserRepository) LogoutUser(ctx context.Context, tokenID string, token s
 	return err
 }
 
-func (r *UserRepository) DeleteUser(ctx context.Context, username string) error {
-	_, err := r.db.Exec("DELETE FROM users WHERE username=$1", username)
-	return err
+// DeleteUser soft-deletes the user, a non-zero version must match the stored one.
+func (r *UserRepository) DeleteUser(ctx context.Context, username string, version int64) error {
+	query := `UPDATE users
+			 SET deleted_at = NOW(), version = version + 1
+			 WHERE username = $1 AND deleted_at IS NULL AND ($2 = 0 OR version = $2)`
+	res, err := r.db.ExecContext(ctx, query, username, version)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	return r.checkAffected(ctx, res, "username = $1", username, version)
+}
+
+// RestoreUser brings back the most recently deleted user with that name,
+// unless the name has been taken again in the meantime.
+func (r *UserRepository) RestoreUser(ctx context.Context, username string) error {
+	if _, err := r.GetUserByName(ctx, username); err == nil {
+		return fmt.Errorf("user %s: %w", username, entity.ErrConflict)
+	}
+
+	query := `UPDATE users
+			 SET deleted_at = NULL, version = version + 1
+			 WHERE id = (
+				SELECT id FROM users
+				WHERE username = $1 AND deleted_at IS NOT NULL
+				ORDER BY deleted_at DESC
+				LIMIT 1
+			 )`
+	res, err := r.db.ExecContext(ctx, query, username)
+	if err != nil {
+		return fmt.Errorf("failed to restore user: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("deleted user %s: %w", username, entity.ErrNotFound)
+	}
+	return nil
+}
+
+func (r *UserRepository) PurgeDeletedUsers(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM users WHERE deleted_at < $1", before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted users: %w", err)
+	}
+	return res.RowsAffected()
 }
 
 func (r *UserRepository) GetUserByName(ctx context.Context, username string) (petstore.User, error) {
-	var user petstore.User
-	query := `SELECT *
+	user, _, err := r.GetVersionedUser(ctx, username, false)
+	return user, err
+}
+
+// GetVersionedUser returns the active user, or with includeDeleted the most recently deleted one
+// when no active user has that name.
+func (r *UserRepository) GetVersionedUser(ctx context.Context, username string, includeDeleted bool) (petstore.User, int64, error) {
+	var user versionedUser
+	query := `SELECT ` + userColumns + `, version
+			 FROM users 
+			 WHERE username = $1 AND deleted_at IS NULL`
+	if includeDeleted {
+		query = `SELECT ` + userColumns + `, version
 			 FROM users 
-			 WHERE username = $1`
+			 WHERE username = $1
+			 ORDER BY deleted_at DESC NULLS FIRST
+			 LIMIT 1`
+	}
 	err := r.db.GetContext(ctx, &user, query, username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return petstore.User{}, 0, fmt.Errorf("user %s: %w", username, entity.ErrNotFound)
+	}
 	if err != nil {
-		return petstore.User{}, fmt.Errorf("failed to get order by name: %w", err)
+		return petstore.User{}, 0, fmt.Errorf("failed to get user by name: %w", err)
 	}
-	return user, nil
+	return user.User, user.Version, nil
 }
 
-func (r *UserRepository) UpdateUser(ctx context.Context, user petstore.User) error {
+// UpdateUser overwrites the user, a non-zero version must match the stored one.
+func (r *UserRepository) UpdateUser(ctx context.Context, user petstore.User, version int64) error {
 	query := `UPDATE users 
-			 SET username = $1, firstName = $2, lastName = $3, password = $4, email = $5, phone = $6, userStatus = $7
-			 WHERE id = $8`
-	_, err := r.db.ExecContext(ctx, query, user.Username, user.FirstName, user.LastName, user.Password, user.Email, user.Phone, user.UserStatus, user.Id)
+			 SET username = $1, firstName = $2, lastName = $3, password = $4, email = $5, phone = $6, userStatus = $7, version = version + 1
+			 WHERE id = $8 AND deleted_at IS NULL AND ($9 = 0 OR version = $9)`
+	res, err := r.db.ExecContext(ctx, query, user.Username, user.FirstName, user.LastName, user.Password, user.Email, user.Phone, user.UserStatus, user.Id, version)
 	if err != nil {
 		return fmt.Errorf("failed to update user: %w", err)
 	}
+	return r.checkAffected(ctx, res, "id = $1", user.Id, version)
+}
+
+func (r *UserRepository) GetUserRole(ctx context.Context, username string) (entity.Role, error) {
+	var role entity.Role
+	err := r.db.GetContext(ctx, &role, "SELECT role FROM users WHERE username = $1 AND deleted_at IS NULL", username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("user %s: %w", username, entity.ErrNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get user role: %w", err)
+	}
+	return role, nil
+}
+
+// SetUserRole changes the role of the active user and returns the previous one. The role
+// isn't part of the user representation, so its version is kept.
+func (r *UserRepository) SetUserRole(ctx context.Context, username string, role entity.Role) (entity.Role, error) {
+	var previous entity.Role
+	err := r.db.GetContext(ctx, &previous, `
+		UPDATE users u
+		SET role = $2
+		FROM (SELECT id, role FROM users WHERE username = $1 AND deleted_at IS NULL FOR UPDATE) old
+		WHERE u.id = old.id
+		RETURNING old.role`,
+		username, role,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("user %s: %w", username, entity.ErrNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to set user role: %w", err)
+	}
+	return previous, nil
+}
+
+// checkAffected tells a missing user from a version mismatch when a conditional write changed nothing.
+func (r *UserRepository) checkAffected(ctx context.Context, res sql.Result, where string, key interface{}, version int64) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+
+	var current int64
+	err = r.db.GetContext(ctx, &current, "SELECT version FROM users WHERE deleted_at IS NULL AND "+where, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("user: %w", entity.ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user version: %w", err)
+	}
+	return fmt.Errorf("user version is %d, not %d: %w", current, version, entity.ErrPreconditionFailed)
+}
+
+// ExportUsers streams active users matching the filter, ordered by id. Passwords are never exported.
+func (r *UserRepository) ExportUsers(ctx context.Context, filter entity.UserFilter, each func(user entity.UserRecord) error) error {
+	var args []interface{}
+	query := `SELECT id, COALESCE(username, '') AS username, COALESCE(firstName, '') AS firstName,
+			 COALESCE(lastName, '') AS lastName, COALESCE(email, '') AS email, COALESCE(phone, '') AS phone,
+			 COALESCE(userStatus, 0) AS userStatus
+			 FROM users
+			 WHERE deleted_at IS NULL`
+	if filter.UserStatus != nil {
+		args = append(args, *filter.UserStatus)
+		query += fmt.Sprintf(" AND userStatus = $%d", len(args))
+	}
+	query += " ORDER BY id"
+
+	err := postgres.EachRow(ctx, r.db, query, args, func(rows *sqlx.Rows) error {
+		var user entity.UserRecord
+		if err := rows.StructScan(&user); err != nil {
+			return fmt.Errorf("failed to scan user row: %w", err)
+		}
+		return each(user)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export users: %w", err)
+	}
 	return nil
 }
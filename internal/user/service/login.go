@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"swagger_petstore/backoff"
+	"swagger_petstore/entity"
+	auditService "swagger_petstore/internal/audit/service"
+	"swagger_petstore/logging"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// freeLoginFailures are the failures of a username not slowing its next login down.
+	freeLoginFailures = 1
+	loginDelay        = time.Second
+	maxLoginDelay     = 30 * time.Second
+)
+
+// lockout is the audit record of a locked username or client IP.
+type lockout struct {
+	Failures    int       `json:"failures"`
+	LockedUntil time.Time `json:"lockedUntil"`
+	ClientIP    string    `json:"clientIp,omitempty"`
+}
+
+func userKey(username string) string {
+	return "user:" + username
+}
+
+func ipKey(clientIP string) string {
+	return "ip:" + clientIP
+}
+
+// checkLogin returns a RetryError while the username or the client IP is locked, or while
+// the username waits out the delay growing with every failure since the last success.
+// Rejected attempts aren't counted as failures.
+func (s *UserService) checkLogin(ctx context.Context, username, clientIP string) error {
+	var keys []string
+	if s.auth.LockoutThreshold > 0 {
+		keys = append(keys, userKey(username))
+	}
+	if s.auth.LockoutIPThreshold > 0 && clientIP != "" {
+		keys = append(keys, ipKey(clientIP))
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	failures, err := s.repository.GetLoginFailures(ctx, keys)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	var wait time.Duration
+	for _, f := range failures {
+		if f.LockedUntil != nil {
+			wait = max(wait, f.LockedUntil.Sub(now))
+		}
+		if f.Key == userKey(username) {
+			wait = max(wait, f.LastFailedAt.Add(failureDelay(f.Failures)).Sub(now))
+		}
+	}
+	if wait > 0 {
+		return &entity.RetryError{Err: fmt.Errorf("%w: login temporarily blocked", entity.ErrTooManyAttempts), After: wait}
+	}
+	return nil
+}
+
+// failureDelay doubles from loginDelay with every failure after the free ones.
+func failureDelay(failures int) time.Duration {
+	return backoff.Exponential(loginDelay, maxLoginDelay, failures-freeLoginFailures)
+}
+
+// loginFailed counts the failure for the username and the client IP and locks the ones
+// reaching their threshold. The count is kept even if the client goes away.
+func (s *UserService) loginFailed(ctx context.Context, username, clientIP string) {
+	store := context.WithoutCancel(ctx)
+	now := time.Now()
+	logger := logging.FromContext(ctx)
+
+	count := func(key string, threshold int, entityType, entityId string) {
+		failures, err := s.repository.AddLoginFailure(store, key, now, now.Add(-s.auth.LockoutDuration))
+		if err != nil {
+			logger.Error("user: failed to count login failure", zap.String("key", key), zap.Error(err))
+			return
+		}
+		if failures.Failures < threshold {
+			return
+		}
+
+		until := now.Add(s.auth.LockoutDuration)
+		if err := s.repository.LockLogin(store, key, until); err != nil {
+			logger.Error("user: failed to lock login", zap.String("key", key), zap.Error(err))
+			return
+		}
+		logger.Warn("user: login locked", zap.String("key", key), zap.Int("failures", failures.Failures), zap.Time("until", until))
+		s.audit.Record(store, entityType, entityId, auditService.ActionLock, nil,
+			lockout{Failures: failures.Failures, LockedUntil: until, ClientIP: clientIP})
+	}
+
+	if s.auth.LockoutThreshold > 0 {
+		count(userKey(username), s.auth.LockoutThreshold, auditService.EntityUser, username)
+	}
+	if s.auth.LockoutIPThreshold > 0 && clientIP != "" {
+		count(ipKey(clientIP), s.auth.LockoutIPThreshold, auditService.EntityClient, clientIP)
+	}
+}
+
+// loginSucceeded forgets the failures of the username. Those of the client IP are kept, so
+// a valid account doesn't reset the count of an IP trying many usernames.
+func (s *UserService) loginSucceeded(ctx context.Context, username string) {
+	if s.auth.LockoutThreshold == 0 {
+		return
+	}
+	if _, err := s.repository.ResetLoginFailures(context.WithoutCancel(ctx), userKey(username)); err != nil {
+		logging.FromContext(ctx).Error("user: failed to reset login failures", zap.Error(err))
+	}
+}
+
+// UnlockUser lifts the lockout of a username and forgets its failed logins.
+func (s *UserService) UnlockUser(ctx context.Context, username string) error {
+	if username == "" {
+		return fmt.Errorf("%w: username required", entity.ErrInvalid)
+	}
+	unlocked, err := s.repository.ResetLoginFailures(ctx, userKey(username))
+	if err != nil {
+		return err
+	}
+	if !unlocked {
+		return fmt.Errorf("failed logins of user %s: %w", username, entity.ErrNotFound)
+	}
+	s.audit.Record(ctx, auditService.EntityUser, username, auditService.ActionUnlock, nil, nil)
+	return nil
+}
+
+// PurgeLoginFailures deletes the failures too old to count whose lockout is over.
+func (s *UserService) PurgeLoginFailures(ctx context.Context) (int64, error) {
+	return s.repository.PurgeLoginFailures(ctx, time.Now().Add(-s.auth.LockoutDuration))
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"swagger_petstore/config"
+	"swagger_petstore/entity"
+	auditService "swagger_petstore/internal/audit/service"
+	"swagger_petstore/internal/user/repository"
+	"testing"
+	"time"
+)
+
+// fakeRepository counts the login failures in memory like the login_failures table. The
+// other methods aren't used by the login checks.
+type fakeRepository struct {
+	repository.UsersRepository
+	failures map[string]entity.LoginFailures
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{failures: map[string]entity.LoginFailures{}}
+}
+
+func (r *fakeRepository) GetLoginFailures(_ context.Context, keys []string) ([]entity.LoginFailures, error) {
+	var failures []entity.LoginFailures
+	for _, key := range keys {
+		if f, ok := r.failures[key]; ok {
+			failures = append(failures, f)
+		}
+	}
+	return failures, nil
+}
+
+func (r *fakeRepository) AddLoginFailure(_ context.Context, key string, now, since time.Time) (entity.LoginFailures, error) {
+	f, ok := r.failures[key]
+	if !ok || f.LastFailedAt.Before(since) {
+		f.Key, f.Failures = key, 0
+	}
+	f.Failures++
+	f.LastFailedAt = now
+	r.failures[key] = f
+	return f, nil
+}
+
+func (r *fakeRepository) LockLogin(_ context.Context, key string, until time.Time) error {
+	f := r.failures[key]
+	f.LockedUntil = &until
+	f.Failures = 0
+	r.failures[key] = f
+	return nil
+}
+
+func (r *fakeRepository) ResetLoginFailures(_ context.Context, key string) (bool, error) {
+	_, ok := r.failures[key]
+	delete(r.failures, key)
+	return ok, nil
+}
+
+// age moves the last failure of key back in time.
+func (r *fakeRepository) age(key string, d time.Duration) {
+	f := r.failures[key]
+	f.LastFailedAt = f.LastFailedAt.Add(-d)
+	r.failures[key] = f
+}
+
+type record struct {
+	entityType, entityId, action string
+}
+
+type fakeRecorder struct {
+	records []record
+}
+
+func (r *fakeRecorder) Record(_ context.Context, entityType, entityId, action string, _, _ interface{}) {
+	r.records = append(r.records, record{entityType, entityId, action})
+}
+
+func newLoginService(threshold, ipThreshold int) (*UserService, *fakeRepository, *fakeRecorder) {
+	repo, audit := newFakeRepository(), &fakeRecorder{}
+	auth := config.AuthConfig{
+		LockoutThreshold:   threshold,
+		LockoutIPThreshold: ipThreshold,
+		LockoutDuration:    15 * time.Minute,
+	}
+	return NewUserService(repo, audit, auth), repo, audit
+}
+
+// retryAfter returns how long checkLogin asks to wait, or 0 when the login may go on.
+func retryAfter(t *testing.T, err error) time.Duration {
+	t.Helper()
+	if err == nil {
+		return 0
+	}
+	var retry *entity.RetryError
+	if !errors.As(err, &retry) || !errors.Is(err, entity.ErrTooManyAttempts) {
+		t.Fatalf("checkLogin = %v, want a RetryError", err)
+	}
+	return retry.After
+}
+
+func TestFailureDelay(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{1, 0},
+		{2, time.Second},
+		{3, 2 * time.Second},
+		{4, 4 * time.Second},
+		{6, 16 * time.Second},
+		{7, maxLoginDelay},
+		{100, maxLoginDelay},
+	}
+	for _, tt := range tests {
+		if got := failureDelay(tt.failures); got != tt.want {
+			t.Errorf("failureDelay(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestLoginDelay(t *testing.T) {
+	ctx := context.Background()
+	s, repo, _ := newLoginService(10, 0)
+	key := userKey("alice")
+
+	s.loginFailed(ctx, "alice", "")
+	if wait := retryAfter(t, s.checkLogin(ctx, "alice", "")); wait != 0 {
+		t.Errorf("first failure delays the next login by %v", wait)
+	}
+
+	s.loginFailed(ctx, "alice", "")
+	if wait := retryAfter(t, s.checkLogin(ctx, "alice", "")); wait <= 0 || wait > time.Second {
+		t.Errorf("second failure delays the next login by %v, want up to 1s", wait)
+	}
+	if wait := retryAfter(t, s.checkLogin(ctx, "bob", "")); wait != 0 {
+		t.Errorf("failures of alice delay bob by %v", wait)
+	}
+
+	repo.age(key, time.Second)
+	if wait := retryAfter(t, s.checkLogin(ctx, "alice", "")); wait != 0 {
+		t.Errorf("login still delayed by %v after the delay", wait)
+	}
+
+	s.loginFailed(ctx, "alice", "")
+	if wait := retryAfter(t, s.checkLogin(ctx, "alice", "")); wait <= time.Second || wait > 2*time.Second {
+		t.Errorf("third failure delays the next login by %v, want up to 2s", wait)
+	}
+
+	s.loginSucceeded(ctx, "alice")
+	if wait := retryAfter(t, s.checkLogin(ctx, "alice", "")); wait != 0 {
+		t.Errorf("login delayed by %v after a success", wait)
+	}
+}
+
+func TestUserLockout(t *testing.T) {
+	ctx := context.Background()
+	s, repo, audit := newLoginService(3, 0)
+	key := userKey("alice")
+
+	for range 2 {
+		s.loginFailed(ctx, "alice", "10.0.0.1")
+		repo.age(key, maxLoginDelay)
+	}
+	if repo.failures[key].LockedUntil != nil || len(audit.records) != 0 {
+		t.Fatalf("locked below the threshold")
+	}
+
+	s.loginFailed(ctx, "alice", "10.0.0.1")
+	f := repo.failures[key]
+	if f.LockedUntil == nil || f.Failures != 0 {
+		t.Fatalf("failures = %+v, want locked with the count started over", f)
+	}
+	want := record{auditService.EntityUser, "alice", auditService.ActionLock}
+	if len(audit.records) != 1 || audit.records[0] != want {
+		t.Errorf("audit = %+v, want %+v", audit.records, want)
+	}
+	if wait := retryAfter(t, s.checkLogin(ctx, "alice", "")); wait <= 14*time.Minute || wait > 15*time.Minute {
+		t.Errorf("locked login waits %v, want the lockout duration", wait)
+	}
+	if _, ok := repo.failures[ipKey("10.0.0.1")]; ok {
+		t.Errorf("failures of the client IP counted with the IP lockout disabled")
+	}
+
+	if err := s.UnlockUser(ctx, "alice"); err != nil {
+		t.Fatalf("UnlockUser: %v", err)
+	}
+	if wait := retryAfter(t, s.checkLogin(ctx, "alice", "")); wait != 0 {
+		t.Errorf("unlocked login waits %v", wait)
+	}
+	if err := s.UnlockUser(ctx, "alice"); !errors.Is(err, entity.ErrNotFound) {
+		t.Errorf("UnlockUser without failures = %v, want ErrNotFound", err)
+	}
+}
+
+func TestClientIPLockout(t *testing.T) {
+	ctx := context.Background()
+	s, _, audit := newLoginService(10, 3)
+
+	for _, username := range []string{"alice", "bob", "carol"} {
+		s.loginFailed(ctx, username, "10.0.0.1")
+	}
+	want := record{auditService.EntityClient, "10.0.0.1", auditService.ActionLock}
+	if len(audit.records) != 1 || audit.records[0] != want {
+		t.Errorf("audit = %+v, want %+v", audit.records, want)
+	}
+
+	if wait := retryAfter(t, s.checkLogin(ctx, "dave", "10.0.0.1")); wait <= 14*time.Minute {
+		t.Errorf("locked client IP waits %v, want the lockout duration", wait)
+	}
+	if wait := retryAfter(t, s.checkLogin(ctx, "dave", "10.0.0.2")); wait != 0 {
+		t.Errorf("another client IP waits %v", wait)
+	}
+
+	// A valid account doesn't lift the lockout of the client IP.
+	s.loginSucceeded(ctx, "dave")
+	if wait := retryAfter(t, s.checkLogin(ctx, "dave", "10.0.0.1")); wait == 0 {
+		t.Errorf("client IP unlocked by a successful login")
+	}
+}
+
+func TestLockoutDisabled(t *testing.T) {
+	ctx := context.Background()
+	s, repo, audit := newLoginService(0, 0)
+
+	for range 10 {
+		s.loginFailed(ctx, "alice", "10.0.0.1")
+	}
+	if len(repo.failures) != 0 || len(audit.records) != 0 {
+		t.Errorf("failures counted with the lockout disabled: %+v", repo.failures)
+	}
+	if err := s.checkLogin(ctx, "alice", "10.0.0.1"); err != nil {
+		t.Errorf("checkLogin = %v, want nil", err)
+	}
+}
@@ -2,7 +2,12 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"swagger_petstore/authz"
+	"swagger_petstore/config"
+	"swagger_petstore/entity"
+	auditService "swagger_petstore/internal/audit/service"
 	"swagger_petstore/internal/user/repository"
 	"swagger_petstore/middleware"
 	"swagger_petstore/petstore"
@@ -16,16 +21,26 @@ type Servicer interface {
 	CreateUsersWithListInput(ctx context.Context, users []petstore.User) error
 	LoginUser(ctx context.Context, params petstore.LoginUserParams) (string, error)
 	LogoutUser(ctx context.Context, tokenID string, token string, exp time.Time) error
-	DeleteUser(ctx context.Context, username string) error
+	DeleteUser(ctx context.Context, username string, version int64) error
 	GetUserByName(ctx context.Context, username string) (petstore.User, error)
-	UpdateUser(ctx context.Context, user petstore.User) error
+	GetVersionedUser(ctx context.Context, username string, includeDeleted bool) (petstore.User, int64, error)
+	RestoreUser(ctx context.Context, username string) error
+	PurgeDeletedUsers(ctx context.Context, retention time.Duration) (int64, error)
+	UnlockUser(ctx context.Context, username string) error
+	PurgeLoginFailures(ctx context.Context) (int64, error)
+	GetUserRole(ctx context.Context, username string) (entity.Role, error)
+	SetUserRole(ctx context.Context, username string, role entity.Role) error
+	UpdateUser(ctx context.Context, username string, user petstore.User, version int64) error
+	ExportUsers(ctx context.Context, filter entity.UserFilter, each func(user entity.UserRecord) error) error
 }
 type UserService struct {
 	repository repository.UsersRepository
+	audit      auditService.Recorder
+	auth       config.AuthConfig
 }
 
-func NewUserService(repository repository.UsersRepository) *UserService {
-	return &UserService{repository: repository}
+func NewUserService(repository repository.UsersRepository, audit auditService.Recorder, auth config.AuthConfig) *UserService {
+	return &UserService{repository: repository, audit: audit, auth: auth}
 }
 
 func (s *UserService) CreateUser(ctx context.Context, user petstore.User) error {
@@ -37,30 +52,52 @@ func (s *UserService) CreateUser(ctx context.Context, user petstore.User) error
 		}
 	}
 
-	return s.repository.CreateUser(ctx, user)
+	if err := s.repository.CreateUser(ctx, user); err != nil {
+		return err
+	}
+	s.record(ctx, user, auditService.ActionCreate, nil, &user)
+	return nil
 }
 
 func (s *UserService) CreateUsersWithListInput(ctx context.Context, users []petstore.User) error {
 	if users == nil {
 		return fmt.Errorf("need list of users")
 	}
-	return s.repository.CreateUsersWithListInput(ctx, users)
+	if err := s.repository.CreateUsersWithListInput(ctx, users); err != nil {
+		return err
+	}
+	for i := range users {
+		s.record(ctx, users[i], auditService.ActionCreate, nil, &users[i])
+	}
+	return nil
 }
 
+// LoginUser returns a token for valid credentials. Failed logins slow down and then lock
+// the username and the client IP for a while, see checkLogin.
 func (s *UserService) LoginUser(ctx context.Context, params petstore.LoginUserParams) (string, error) {
 	if params.Username == nil || params.Password == nil {
-		return "", fmt.Errorf("need login information")
+		return "", fmt.Errorf("%w: need login information", entity.ErrInvalid)
 	}
 	tokenAuth := middleware.TokenAuth
+	username, clientIP := *params.Username, middleware.ClientIP(ctx)
+	if err := s.checkLogin(ctx, username, clientIP); err != nil {
+		return "", err
+	}
 
 	user, err := s.repository.LoginUser(ctx, params)
+	if errors.Is(err, entity.ErrNotFound) {
+		s.loginFailed(ctx, username, clientIP)
+		return "", entity.ErrUnauthorized
+	}
 	if err != nil {
-		return "", fmt.Errorf("Unauthorized")
+		return "", err
 	}
+	s.loginSucceeded(ctx, username)
+
 	claims := map[string]interface{}{
 		"user_id": user.Username,
 		"jti":     uuid.New().String(),
-		"exp":     time.Now().Add(time.Hour).Unix(),
+		"exp":     time.Now().Add(s.auth.TokenTTL).Unix(),
 	}
 	_, token, err := tokenAuth.Encode(claims)
 	if err != nil {
@@ -77,20 +114,142 @@ func (s *UserService) LogoutUser(ctx context.Context, tokenID string, token stri
 	return s.repository.LogoutUser(ctx, tokenID, token, exp)
 }
 
-func (s *UserService) DeleteUser(ctx context.Context, username string) error {
+// DeleteUser deletes the account of the caller, admins may delete any account.
+func (s *UserService) DeleteUser(ctx context.Context, username string, version int64) error {
 	if username == "" {
 		return fmt.Errorf("username required")
 	}
-	return s.repository.DeleteUser(ctx, username)
+	if err := authz.RequireSelfOr(ctx, username, entity.RoleAdmin); err != nil {
+		return err
+	}
+	before, err := s.repository.GetUserByName(ctx, username)
+	if err != nil {
+		return err
+	}
+	if err := s.repository.DeleteUser(ctx, username, version); err != nil {
+		return err
+	}
+	s.record(ctx, before, auditService.ActionDelete, &before, nil)
+	return nil
 }
 
 func (s *UserService) GetUserByName(ctx context.Context, username string) (petstore.User, error) {
 	if username == "" {
 		return petstore.User{}, fmt.Errorf("username required")
 	}
-	return s.repository.GetUserByName(ctx, username)
+	user, err := s.repository.GetUserByName(ctx, username)
+	if err != nil {
+		return petstore.User{}, err
+	}
+	return *withoutPassword(&user), nil
+}
+
+func (s *UserService) GetVersionedUser(ctx context.Context, username string, includeDeleted bool) (petstore.User, int64, error) {
+	if username == "" {
+		return petstore.User{}, 0, fmt.Errorf("username required")
+	}
+	user, version, err := s.repository.GetVersionedUser(ctx, username, includeDeleted)
+	if err != nil {
+		return petstore.User{}, 0, err
+	}
+	return *withoutPassword(&user), version, nil
+}
+
+func (s *UserService) RestoreUser(ctx context.Context, username string) error {
+	if username == "" {
+		return fmt.Errorf("username required")
+	}
+	if err := s.repository.RestoreUser(ctx, username); err != nil {
+		return err
+	}
+	if after, err := s.repository.GetUserByName(ctx, username); err == nil {
+		s.record(ctx, after, auditService.ActionRestore, nil, &after)
+	}
+	return nil
+}
+
+// PurgeDeletedUsers permanently removes users that were soft-deleted longer than retention ago.
+func (s *UserService) PurgeDeletedUsers(ctx context.Context, retention time.Duration) (int64, error) {
+	return s.repository.PurgeDeletedUsers(ctx, time.Now().Add(-retention))
+}
+
+// UpdateUser replaces the account of the caller, admins may replace any account. The user
+// is the one named in the path whatever the id of the body.
+func (s *UserService) UpdateUser(ctx context.Context, username string, user petstore.User, version int64) error {
+	if err := authz.RequireSelfOr(ctx, username, entity.RoleAdmin); err != nil {
+		return err
+	}
+	before, err := s.repository.GetUserByName(ctx, username)
+	if err != nil {
+		return err
+	}
+	// Tokens and the audit trail name users by username, so it can't be changed.
+	if user.Username == nil {
+		user.Username = &username
+	} else if *user.Username != username {
+		return fmt.Errorf("%w: username can't be changed", entity.ErrInvalid)
+	}
+	user.Id = before.Id
+	if err := s.repository.UpdateUser(ctx, user, version); err != nil {
+		return err
+	}
+	s.record(ctx, before, auditService.ActionUpdate, &before, &user)
+	return nil
 }
 
-func (s *UserService) UpdateUser(ctx context.Context, user petstore.User) error {
-	return s.repository.UpdateUser(ctx, user)
+func (s *UserService) GetUserRole(ctx context.Context, username string) (entity.Role, error) {
+	if username == "" {
+		return "", fmt.Errorf("%w: username required", entity.ErrInvalid)
+	}
+	return s.repository.GetUserRole(ctx, username)
+}
+
+// SetUserRole assigns the role to the user. Admins can't change their own role, so there
+// is always one left.
+func (s *UserService) SetUserRole(ctx context.Context, username string, role entity.Role) error {
+	if username == "" {
+		return fmt.Errorf("%w: username required", entity.ErrInvalid)
+	}
+	if _, err := entity.ParseRole(string(role)); err != nil {
+		return err
+	}
+	if principal, ok := authz.FromContext(ctx); ok && principal.Username == username {
+		return fmt.Errorf("%w: admins can't change their own role", entity.ErrForbidden)
+	}
+	previous, err := s.repository.SetUserRole(ctx, username, role)
+	if err != nil {
+		return err
+	}
+	if previous != role {
+		s.audit.Record(ctx, auditService.EntityUser, username, auditService.ActionUpdate,
+			userRole{Role: previous}, userRole{Role: role})
+	}
+	return nil
+}
+
+func (s *UserService) ExportUsers(ctx context.Context, filter entity.UserFilter, each func(user entity.UserRecord) error) error {
+	return s.repository.ExportUsers(ctx, filter, each)
+}
+
+// record writes the change to the audit trail without the password.
+func (s *UserService) record(ctx context.Context, user petstore.User, action string, before, after *petstore.User) {
+	var username string
+	if user.Username != nil {
+		username = *user.Username
+	}
+	s.audit.Record(ctx, auditService.EntityUser, username, action, withoutPassword(before), withoutPassword(after))
+}
+
+// userRole is the audit record of a role change.
+type userRole struct {
+	Role entity.Role `json:"role"`
+}
+
+func withoutPassword(user *petstore.User) *petstore.User {
+	if user == nil {
+		return nil
+	}
+	safe := *user
+	safe.Password = nil
+	return &safe
 }
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"swagger_petstore/entity"
+	"swagger_petstore/petstore"
+	"swagger_petstore/tracing"
+	"time"
+)
+
+// tracedService starts a span around the methods of the service that can fail.
+type tracedService struct {
+	Servicer
+}
+
+// Traced wraps the service so that its calls are traced.
+func Traced(service Servicer) Servicer {
+	return tracedService{Servicer: service}
+}
+
+func (s tracedService) CreateUser(ctx context.Context, user petstore.User) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.CreateUser")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.CreateUser(ctx, user)
+}
+
+func (s tracedService) CreateUsersWithListInput(ctx context.Context, users []petstore.User) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.CreateUsersWithListInput")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.CreateUsersWithListInput(ctx, users)
+}
+
+func (s tracedService) LoginUser(ctx context.Context, params petstore.LoginUserParams) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.LoginUser")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.LoginUser(ctx, params)
+}
+
+func (s tracedService) LogoutUser(ctx context.Context, tokenID string, token string, exp time.Time) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.LogoutUser")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.LogoutUser(ctx, tokenID, token, exp)
+}
+
+func (s tracedService) DeleteUser(ctx context.Context, username string, version int64) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.DeleteUser")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.DeleteUser(ctx, username, version)
+}
+
+func (s tracedService) GetUserByName(ctx context.Context, username string) (_ petstore.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserByName")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.GetUserByName(ctx, username)
+}
+
+func (s tracedService) GetVersionedUser(ctx context.Context, username string, includeDeleted bool) (_ petstore.User, _ int64, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetVersionedUser")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.GetVersionedUser(ctx, username, includeDeleted)
+}
+
+func (s tracedService) RestoreUser(ctx context.Context, username string) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.RestoreUser")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.RestoreUser(ctx, username)
+}
+
+func (s tracedService) PurgeDeletedUsers(ctx context.Context, retention time.Duration) (_ int64, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.PurgeDeletedUsers")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.PurgeDeletedUsers(ctx, retention)
+}
+
+func (s tracedService) UnlockUser(ctx context.Context, username string) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.UnlockUser")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.UnlockUser(ctx, username)
+}
+
+func (s tracedService) PurgeLoginFailures(ctx context.Context) (_ int64, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.PurgeLoginFailures")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.PurgeLoginFailures(ctx)
+}
+
+func (s tracedService) GetUserRole(ctx context.Context, username string) (_ entity.Role, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserRole")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.GetUserRole(ctx, username)
+}
+
+func (s tracedService) SetUserRole(ctx context.Context, username string, role entity.Role) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.SetUserRole")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.SetUserRole(ctx, username, role)
+}
+
+func (s tracedService) UpdateUser(ctx context.Context, username string, user petstore.User, version int64) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateUser")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.UpdateUser(ctx, username, user, version)
+}
+
+func (s tracedService) ExportUsers(ctx context.Context, filter entity.UserFilter, each func(user entity.UserRecord) error) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.ExportUsers")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.ExportUsers(ctx, filter, each)
+}
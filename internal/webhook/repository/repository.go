@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"swagger_petstore/entity"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type WebhooksRepository interface {
+	CreateSubscription(ctx context.Context, subscription entity.WebhookSubscription) (entity.WebhookSubscription, error)
+	GetSubscriptions(ctx context.Context) ([]entity.WebhookSubscription, error)
+	GetSubscription(ctx context.Context, subscriptionId int64) (entity.WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, subscription entity.WebhookSubscription) error
+	DeleteSubscription(ctx context.Context, subscriptionId int64) error
+	AddDeliveries(ctx context.Context, event entity.Event, body []byte) error
+	GetDeliveries(ctx context.Context, subscriptionId int64, limit, offset int) ([]entity.WebhookDelivery, error)
+	Redeliver(ctx context.Context, subscriptionId, deliveryId int64) error
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]entity.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery entity.WebhookDelivery) error
+	ReleaseDeliveries(ctx context.Context, deliveryIds []int64) error
+}
+type Repository struct {
+	db *sqlx.DB
+}
+
+func NewWebhookRepository(db *sqlx.DB) WebhooksRepository {
+	return &Repository{db: db}
+}
+
+const subscriptionColumns = "id, url, event_types, secret, active, created_at"
+
+// NULL can't be scanned into string or int, so optional columns are read back with defaults.
+const deliveryColumns = `id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+	COALESCE(response_status, 0) AS response_status, COALESCE(last_error, '') AS last_error, created_at, delivered_at`
+
+func scanSubscription(row interface{ Scan(...interface{}) error }) (entity.WebhookSubscription, error) {
+	var subscription entity.WebhookSubscription
+	err := row.Scan(
+		&subscription.Id,
+		&subscription.Url,
+		pq.Array(&subscription.EventTypes),
+		&subscription.Secret,
+		&subscription.Active,
+		&subscription.CreatedAt,
+	)
+	return subscription, err
+}
+
+func (r *Repository) CreateSubscription(ctx context.Context, subscription entity.WebhookSubscription) (entity.WebhookSubscription, error) {
+	created, err := scanSubscription(r.db.QueryRowContext(ctx, `
+		INSERT INTO webhook_subscriptions (url, event_types, secret, active)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+subscriptionColumns,
+		subscription.Url, pq.Array(subscription.EventTypes), subscription.Secret, subscription.Active,
+	))
+	if err != nil {
+		return entity.WebhookSubscription{}, fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+	return created, nil
+}
+
+func (r *Repository) GetSubscriptions(ctx context.Context) ([]entity.WebhookSubscription, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+subscriptionColumns+` FROM webhook_subscriptions ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	subscriptions := []entity.WebhookSubscription{}
+	for rows.Next() {
+		subscription, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook subscription: %w", err)
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+	return subscriptions, rows.Err()
+}
+
+func (r *Repository) GetSubscription(ctx context.Context, subscriptionId int64) (entity.WebhookSubscription, error) {
+	subscription, err := scanSubscription(r.db.QueryRowContext(ctx,
+		`SELECT `+subscriptionColumns+` FROM webhook_subscriptions WHERE id = $1`, subscriptionId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.WebhookSubscription{}, fmt.Errorf("webhook subscription %d: %w", subscriptionId, entity.ErrNotFound)
+	}
+	if err != nil {
+		return entity.WebhookSubscription{}, fmt.Errorf("failed to get webhook subscription: %w", err)
+	}
+	return subscription, nil
+}
+
+func (r *Repository) UpdateSubscription(ctx context.Context, subscription entity.WebhookSubscription) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE webhook_subscriptions
+		SET url = $2, event_types = $3, secret = $4, active = $5
+		WHERE id = $1`,
+		subscription.Id, subscription.Url, pq.Array(subscription.EventTypes), subscription.Secret, subscription.Active,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook subscription: %w", err)
+	}
+	return checkFound(res, "webhook subscription", subscription.Id)
+}
+
+func (r *Repository) DeleteSubscription(ctx context.Context, subscriptionId int64) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, subscriptionId)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+	return checkFound(res, "webhook subscription", subscriptionId)
+}
+
+// AddDeliveries queues the event for every active subscription to its type. An empty
+// list of event types subscribes to all events. A redelivered event is queued only once.
+func (r *Repository) AddDeliveries(ctx context.Context, event entity.Event, body []byte) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload)
+		SELECT id, $1, $2, $3::jsonb
+		FROM webhook_subscriptions
+		WHERE active AND (cardinality(event_types) = 0 OR $2 = ANY(event_types))
+		ON CONFLICT (subscription_id, event_id) DO NOTHING`,
+		event.Id, event.Type, string(body),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) GetDeliveries(ctx context.Context, subscriptionId int64, limit, offset int) ([]entity.WebhookDelivery, error) {
+	deliveries := []entity.WebhookDelivery{}
+	err := r.db.SelectContext(ctx, &deliveries, `
+		SELECT `+deliveryColumns+`
+		FROM webhook_deliveries
+		WHERE subscription_id = $1
+		ORDER BY id DESC
+		LIMIT $2 OFFSET $3`,
+		subscriptionId, limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// Redeliver queues the delivery to be sent again now with a fresh set of attempts.
+func (r *Repository) Redeliver(ctx context.Context, subscriptionId, deliveryId int64) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = 'pending', attempts = 0, next_attempt_at = NOW()
+		WHERE id = $1 AND subscription_id = $2`,
+		deliveryId, subscriptionId,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to redeliver webhook: %w", err)
+	}
+	return checkFound(res, "webhook delivery", deliveryId)
+}
+
+// ClaimDeliveries leases up to limit due deliveries and returns them. The lease keeps other
+// dispatchers away from them until it runs out, so deliveries left over by a dispatcher
+// that stopped are sent again. Sending happens outside of any transaction.
+func (r *Repository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]entity.WebhookDelivery, error) {
+	deliveries := []entity.WebhookDelivery{}
+	err := r.db.SelectContext(ctx, &deliveries, `
+		UPDATE webhook_deliveries SET next_attempt_at = NOW() + $2::interval
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at, id
+			FOR UPDATE SKIP LOCKED
+			LIMIT $1
+		)
+		RETURNING `+deliveryColumns,
+		limit, fmt.Sprintf("%d milliseconds", lease.Milliseconds()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim due webhook deliveries: %w", err)
+	}
+	slices.SortFunc(deliveries, func(a, b entity.WebhookDelivery) int { return cmp.Compare(a.Id, b.Id) })
+	return deliveries, nil
+}
+
+// UpdateDelivery stores the outcome of an attempt.
+func (r *Repository) UpdateDelivery(ctx context.Context, delivery entity.WebhookDelivery) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = $3, next_attempt_at = $4, response_status = NULLIF($5, 0),
+			last_error = NULLIF($6, ''), delivered_at = $7
+		WHERE id = $1`,
+		delivery.Id, delivery.Status, delivery.Attempts, delivery.NextAttemptAt,
+		delivery.ResponseStatus, delivery.Error, delivery.DeliveredAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// ReleaseDeliveries ends the lease of deliveries that were claimed but not sent.
+func (r *Repository) ReleaseDeliveries(ctx context.Context, deliveryIds []int64) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries SET next_attempt_at = NOW()
+		WHERE id = ANY($1) AND status = 'pending'`,
+		pq.Array(deliveryIds),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to release webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+func checkFound(res sql.Result, name string, id int64) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%s %d: %w", name, id, entity.ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"swagger_petstore/backoff"
+	"swagger_petstore/entity"
+	"swagger_petstore/internal/webhook/repository"
+	"swagger_petstore/tracing"
+	"time"
+)
+
+const (
+	SignatureHeader = "X-Petstore-Signature"
+	TimestampHeader = "X-Petstore-Timestamp"
+	EventHeader     = "X-Petstore-Event"
+	DeliveryHeader  = "X-Petstore-Delivery"
+
+	dispatchBatch = 20
+	// dispatchLease outlasts the sending of a batch to slow receivers.
+	dispatchLease      = 5 * time.Minute
+	maxAttempts        = 8
+	retryBackoff       = 30 * time.Second
+	maxBackoff         = 6 * time.Hour
+	deliveryTimeout    = 10 * time.Second
+	secretBytes        = 32
+	defaultDeliveries  = 50
+	maxDeliveries      = 500
+	maxResponseExcerpt = 512
+)
+
+type Servicer interface {
+	CreateSubscription(ctx context.Context, subscription entity.WebhookSubscription) (entity.WebhookSubscription, error)
+	GetSubscriptions(ctx context.Context) ([]entity.WebhookSubscription, error)
+	GetSubscription(ctx context.Context, subscriptionId int64) (entity.WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, subscription entity.WebhookSubscription) error
+	DeleteSubscription(ctx context.Context, subscriptionId int64) error
+	GetDeliveries(ctx context.Context, subscriptionId int64, limit, offset int) ([]entity.WebhookDelivery, error)
+	Redeliver(ctx context.Context, subscriptionId, deliveryId int64) error
+	Publish(ctx context.Context, event entity.Event) error
+	Dispatch(ctx context.Context) error
+}
+type WebhookService struct {
+	repository repository.WebhooksRepository
+	client     *http.Client
+}
+
+func NewWebhookService(repository repository.WebhooksRepository) *WebhookService {
+	return &WebhookService{repository: repository, client: &http.Client{Timeout: deliveryTimeout}}
+}
+
+// CreateSubscription stores the subscription, generating a secret if none is given.
+// The secret is returned only here.
+func (s *WebhookService) CreateSubscription(ctx context.Context, subscription entity.WebhookSubscription) (entity.WebhookSubscription, error) {
+	if subscription.Secret == "" {
+		secret := make([]byte, secretBytes)
+		if _, err := rand.Read(secret); err != nil {
+			return entity.WebhookSubscription{}, err
+		}
+		subscription.Secret = hex.EncodeToString(secret)
+	}
+	if err := validateSubscription(&subscription); err != nil {
+		return entity.WebhookSubscription{}, err
+	}
+	return s.repository.CreateSubscription(ctx, subscription)
+}
+
+func (s *WebhookService) GetSubscriptions(ctx context.Context) ([]entity.WebhookSubscription, error) {
+	subscriptions, err := s.repository.GetSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range subscriptions {
+		subscriptions[i].Secret = ""
+	}
+	return subscriptions, nil
+}
+
+func (s *WebhookService) GetSubscription(ctx context.Context, subscriptionId int64) (entity.WebhookSubscription, error) {
+	subscription, err := s.repository.GetSubscription(ctx, subscriptionId)
+	if err != nil {
+		return entity.WebhookSubscription{}, err
+	}
+	subscription.Secret = ""
+	return subscription, nil
+}
+
+// UpdateSubscription replaces the subscription, an empty secret keeps the current one.
+func (s *WebhookService) UpdateSubscription(ctx context.Context, subscription entity.WebhookSubscription) error {
+	if subscription.Secret == "" {
+		current, err := s.repository.GetSubscription(ctx, subscription.Id)
+		if err != nil {
+			return err
+		}
+		subscription.Secret = current.Secret
+	}
+	if err := validateSubscription(&subscription); err != nil {
+		return err
+	}
+	return s.repository.UpdateSubscription(ctx, subscription)
+}
+
+func (s *WebhookService) DeleteSubscription(ctx context.Context, subscriptionId int64) error {
+	return s.repository.DeleteSubscription(ctx, subscriptionId)
+}
+
+func (s *WebhookService) GetDeliveries(ctx context.Context, subscriptionId int64, limit, offset int) ([]entity.WebhookDelivery, error) {
+	if _, err := s.repository.GetSubscription(ctx, subscriptionId); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultDeliveries
+	}
+	if limit > maxDeliveries {
+		return nil, fmt.Errorf("%w: limit must not exceed %d", entity.ErrInvalid, maxDeliveries)
+	}
+	if offset < 0 {
+		return nil, fmt.Errorf("%w: offset must not be negative", entity.ErrInvalid)
+	}
+	return s.repository.GetDeliveries(ctx, subscriptionId, limit, offset)
+}
+
+func (s *WebhookService) Redeliver(ctx context.Context, subscriptionId, deliveryId int64) error {
+	return s.repository.Redeliver(ctx, subscriptionId, deliveryId)
+}
+
+// Publish queues the event for the subscribed webhooks, it makes the service an outbox sink.
+func (s *WebhookService) Publish(ctx context.Context, event entity.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return s.repository.AddDeliveries(ctx, event, body)
+}
+
+// Dispatch sends the due deliveries until none are left. The outcome of a delivery is
+// stored even when ctx is cancelled meanwhile, the deliveries left unsent are released.
+func (s *WebhookService) Dispatch(ctx context.Context) error {
+	store := context.WithoutCancel(ctx)
+	for {
+		deliveries, err := s.repository.ClaimDeliveries(ctx, dispatchBatch, dispatchLease)
+		if err != nil {
+			return err
+		}
+		subscriptions := map[int64]entity.WebhookSubscription{}
+		for i, delivery := range deliveries {
+			if ctx.Err() != nil {
+				ids := make([]int64, 0, len(deliveries)-i)
+				for _, left := range deliveries[i:] {
+					ids = append(ids, left.Id)
+				}
+				return s.repository.ReleaseDeliveries(store, ids)
+			}
+			subscription, ok := subscriptions[delivery.SubscriptionId]
+			if !ok {
+				subscription, err = s.repository.GetSubscription(ctx, delivery.SubscriptionId)
+				if errors.Is(err, entity.ErrNotFound) {
+					// deleted meanwhile, its deliveries went with it
+					continue
+				}
+				if err != nil {
+					return err
+				}
+				subscriptions[delivery.SubscriptionId] = subscription
+			}
+			if err := s.repository.UpdateDelivery(store, s.send(ctx, delivery, subscription)); err != nil {
+				return err
+			}
+		}
+		if len(deliveries) < dispatchBatch {
+			return nil
+		}
+	}
+}
+
+// send posts the delivery and returns it with the outcome, scheduling the next attempt
+// with exponential backoff on failure.
+func (s *WebhookService) send(ctx context.Context, delivery entity.WebhookDelivery, subscription entity.WebhookSubscription) entity.WebhookDelivery {
+	delivery.Attempts++
+	delivery.ResponseStatus = 0
+	delivery.Error = ""
+
+	status, err := s.post(ctx, delivery, subscription)
+	delivery.ResponseStatus = status
+	now := time.Now()
+	switch {
+	case err == nil:
+		delivery.Status = entity.DeliverySucceeded
+		delivery.NextAttemptAt = nil
+		delivery.DeliveredAt = &now
+	case !subscription.Active || delivery.Attempts >= maxAttempts:
+		delivery.Status = entity.DeliveryFailed
+		delivery.NextAttemptAt = nil
+		delivery.Error = err.Error()
+	default:
+		next := now.Add(backoff.Exponential(retryBackoff, maxBackoff, delivery.Attempts))
+		delivery.Status = entity.DeliveryPending
+		delivery.NextAttemptAt = &next
+		delivery.Error = err.Error()
+	}
+	return delivery
+}
+
+func (s *WebhookService) post(ctx context.Context, delivery entity.WebhookDelivery, subscription entity.WebhookSubscription) (int, error) {
+	if !subscription.Active {
+		return 0, fmt.Errorf("subscription is inactive")
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.Id, 10))
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(subscription.Secret, timestamp, delivery.Payload))
+	tracing.Inject(ctx, req.Header)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseExcerpt))
+		if body := strings.TrimSpace(string(excerpt)); body != "" {
+			return resp.StatusCode, fmt.Errorf("receiver responded %s: %s", resp.Status, body)
+		}
+		return resp.StatusCode, fmt.Errorf("receiver responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the signature header value of a delivery: the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the subscription secret. Receivers recompute it with
+// the X-Petstore-Timestamp header and the raw body and compare in constant time.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func validateSubscription(subscription *entity.WebhookSubscription) error {
+	target, err := url.Parse(subscription.Url)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", entity.ErrInvalid)
+	}
+	if subscription.EventTypes == nil {
+		subscription.EventTypes = []string{}
+	}
+	for _, eventType := range subscription.EventTypes {
+		if !slices.Contains(entity.EventTypes, eventType) {
+			return fmt.Errorf("%w: unknown event type %q", entity.ErrInvalid, eventType)
+		}
+	}
+	return nil
+}
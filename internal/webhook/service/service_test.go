@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"swagger_petstore/entity"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRepository keeps the deliveries in memory. Every pending delivery is due, so each
+// Dispatch makes one attempt at all of them.
+type fakeRepository struct {
+	mu            sync.Mutex
+	subscriptions map[int64]entity.WebhookSubscription
+	deliveries    map[int64]entity.WebhookDelivery
+	updates       []update
+}
+
+type update struct {
+	delivery entity.WebhookDelivery
+	at       time.Time
+}
+
+func newFakeRepository(subscription entity.WebhookSubscription, deliveries ...entity.WebhookDelivery) *fakeRepository {
+	r := &fakeRepository{
+		subscriptions: map[int64]entity.WebhookSubscription{subscription.Id: subscription},
+		deliveries:    map[int64]entity.WebhookDelivery{},
+	}
+	for _, delivery := range deliveries {
+		r.deliveries[delivery.Id] = delivery
+	}
+	return r
+}
+
+func (r *fakeRepository) CreateSubscription(context.Context, entity.WebhookSubscription) (entity.WebhookSubscription, error) {
+	panic("not used")
+}
+
+func (r *fakeRepository) GetSubscriptions(context.Context) ([]entity.WebhookSubscription, error) {
+	panic("not used")
+}
+
+func (r *fakeRepository) GetSubscription(_ context.Context, subscriptionId int64) (entity.WebhookSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	subscription, ok := r.subscriptions[subscriptionId]
+	if !ok {
+		return entity.WebhookSubscription{}, entity.ErrNotFound
+	}
+	return subscription, nil
+}
+
+func (r *fakeRepository) UpdateSubscription(context.Context, entity.WebhookSubscription) error {
+	panic("not used")
+}
+
+func (r *fakeRepository) DeleteSubscription(context.Context, int64) error {
+	panic("not used")
+}
+
+func (r *fakeRepository) AddDeliveries(context.Context, entity.Event, []byte) error {
+	panic("not used")
+}
+
+func (r *fakeRepository) GetDeliveries(context.Context, int64, int, int) ([]entity.WebhookDelivery, error) {
+	panic("not used")
+}
+
+func (r *fakeRepository) Redeliver(_ context.Context, subscriptionId, deliveryId int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delivery, ok := r.deliveries[deliveryId]
+	if !ok || delivery.SubscriptionId != subscriptionId {
+		return entity.ErrNotFound
+	}
+	now := time.Now()
+	delivery.Status = entity.DeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = &now
+	r.deliveries[deliveryId] = delivery
+	return nil
+}
+
+func (r *fakeRepository) ClaimDeliveries(_ context.Context, limit int, _ time.Duration) ([]entity.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	claimed := []entity.WebhookDelivery{}
+	for _, delivery := range r.deliveries {
+		if delivery.Status == entity.DeliveryPending && len(claimed) < limit {
+			claimed = append(claimed, delivery)
+		}
+	}
+	slices.SortFunc(claimed, func(a, b entity.WebhookDelivery) int { return int(a.Id - b.Id) })
+	return claimed, nil
+}
+
+func (r *fakeRepository) UpdateDelivery(_ context.Context, delivery entity.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deliveries[delivery.Id] = delivery
+	r.updates = append(r.updates, update{delivery: delivery, at: time.Now()})
+	return nil
+}
+
+func (r *fakeRepository) ReleaseDeliveries(context.Context, []int64) error {
+	return nil
+}
+
+func (r *fakeRepository) delivery(id int64) entity.WebhookDelivery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.deliveries[id]
+}
+
+// receiver answers with the statuses in turn, then with 204, and records the requests.
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.requests = append(rc.requests, r)
+	rc.bodies = append(rc.bodies, body)
+	status := http.StatusNoContent
+	if len(rc.statuses) > 0 {
+		status, rc.statuses = rc.statuses[0], rc.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func newDelivery(subscriptionId int64) entity.WebhookDelivery {
+	payload, _ := json.Marshal(map[string]any{"type": "pet.created", "entityId": 7})
+	return entity.WebhookDelivery{
+		Id:             1,
+		SubscriptionId: subscriptionId,
+		EventId:        3,
+		EventType:      "pet.created",
+		Payload:        payload,
+		Status:         entity.DeliveryPending,
+	}
+}
+
+func TestDispatchSignsDelivery(t *testing.T) {
+	rc := &receiver{}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	subscription := entity.WebhookSubscription{Id: 1, Url: server.URL, Secret: "s3cret", Active: true}
+	repo := newFakeRepository(subscription, newDelivery(subscription.Id))
+	if err := NewWebhookService(repo).Dispatch(context.Background()); err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+
+	if len(rc.requests) != 1 {
+		t.Fatalf("receiver got %d requests, want 1", len(rc.requests))
+	}
+	req, body := rc.requests[0], rc.bodies[0]
+	if got := req.Header.Get(EventHeader); got != "pet.created" {
+		t.Errorf("%s = %q, want pet.created", EventHeader, got)
+	}
+	if got := req.Header.Get(DeliveryHeader); got != "1" {
+		t.Errorf("%s = %q, want 1", DeliveryHeader, got)
+	}
+	timestamp := req.Header.Get(TimestampHeader)
+	if want := Sign("s3cret", timestamp, body); req.Header.Get(SignatureHeader) != want {
+		t.Errorf("%s = %q, want %q", SignatureHeader, req.Header.Get(SignatureHeader), want)
+	}
+	if Sign("other", timestamp, body) == req.Header.Get(SignatureHeader) {
+		t.Errorf("signature does not depend on the secret")
+	}
+
+	delivery := repo.delivery(1)
+	if delivery.Status != entity.DeliverySucceeded || delivery.Attempts != 1 || delivery.DeliveredAt == nil {
+		t.Errorf("delivery = %+v, want succeeded after 1 attempt", delivery)
+	}
+	if delivery.ResponseStatus != http.StatusNoContent {
+		t.Errorf("response status = %d, want %d", delivery.ResponseStatus, http.StatusNoContent)
+	}
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '1700000000.{}' | openssl dgst -sha256 -hmac secret
+	want := "sha256=b8569b78799ff9e3cbff0fc2d63a33a2b57f3282abd07c37ae5e8e7d79a5f163"
+	if got := Sign("secret", "1700000000", []byte("{}")); got != want {
+		t.Errorf("Sign = %q, want %q", got, want)
+	}
+	if Sign("secret", "1700000000", []byte("{}")) == Sign("secret", "1700000001", []byte("{}")) {
+		t.Errorf("signature does not depend on the timestamp")
+	}
+}
+
+func TestDispatchRetriesWithBackoff(t *testing.T) {
+	statuses := make([]int, maxAttempts)
+	for i := range statuses {
+		statuses[i] = http.StatusInternalServerError
+	}
+	rc := &receiver{statuses: statuses}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	subscription := entity.WebhookSubscription{Id: 1, Url: server.URL, Secret: "s3cret", Active: true}
+	repo := newFakeRepository(subscription, newDelivery(subscription.Id))
+	service := NewWebhookService(repo)
+	for range maxAttempts {
+		if err := service.Dispatch(context.Background()); err != nil {
+			t.Fatalf("Dispatch: %v", err)
+		}
+	}
+
+	if len(repo.updates) != maxAttempts {
+		t.Fatalf("got %d attempts, want %d", len(repo.updates), maxAttempts)
+	}
+	delay := retryBackoff
+	for i, u := range repo.updates[:maxAttempts-1] {
+		if u.delivery.Status != entity.DeliveryPending || u.delivery.Attempts != i+1 {
+			t.Fatalf("attempt %d: delivery = %+v, want pending", i+1, u.delivery)
+		}
+		if u.delivery.ResponseStatus != http.StatusInternalServerError || u.delivery.Error == "" {
+			t.Errorf("attempt %d: response %d, error %q, want 500 and an error", i+1, u.delivery.ResponseStatus, u.delivery.Error)
+		}
+		got := u.delivery.NextAttemptAt.Sub(u.at)
+		if got < delay-time.Second || got > delay {
+			t.Errorf("attempt %d: retried in %v, want %v", i+1, got, delay)
+		}
+		delay = min(2*delay, maxBackoff)
+	}
+	last := repo.delivery(1)
+	if last.Status != entity.DeliveryFailed || last.Attempts != maxAttempts || last.NextAttemptAt != nil {
+		t.Errorf("delivery = %+v, want failed after %d attempts", last, maxAttempts)
+	}
+
+	if err := service.Dispatch(context.Background()); err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	if len(rc.requests) != maxAttempts {
+		t.Errorf("failed delivery was sent again")
+	}
+}
+
+func TestRedeliver(t *testing.T) {
+	rc := &receiver{}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	subscription := entity.WebhookSubscription{Id: 1, Url: server.URL, Secret: "s3cret", Active: true}
+	failed := newDelivery(subscription.Id)
+	failed.Status = entity.DeliveryFailed
+	failed.Attempts = maxAttempts
+	failed.Error = "receiver responded 500 Internal Server Error"
+	repo := newFakeRepository(subscription, failed)
+	service := NewWebhookService(repo)
+
+	if err := service.Redeliver(context.Background(), subscription.Id, failed.Id); err != nil {
+		t.Fatalf("Redeliver: %v", err)
+	}
+	if err := service.Dispatch(context.Background()); err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+
+	if len(rc.requests) != 1 {
+		t.Fatalf("receiver got %d requests, want 1", len(rc.requests))
+	}
+	delivery := repo.delivery(failed.Id)
+	if delivery.Status != entity.DeliverySucceeded || delivery.Attempts != 1 || delivery.Error != "" {
+		t.Errorf("delivery = %+v, want succeeded after 1 attempt", delivery)
+	}
+}
+
+func TestDispatchInactiveSubscription(t *testing.T) {
+	rc := &receiver{}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	subscription := entity.WebhookSubscription{Id: 1, Url: server.URL, Secret: "s3cret", Active: false}
+	repo := newFakeRepository(subscription, newDelivery(subscription.Id))
+	if err := NewWebhookService(repo).Dispatch(context.Background()); err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+
+	if len(rc.requests) != 0 {
+		t.Errorf("inactive subscription received %d requests", len(rc.requests))
+	}
+	if delivery := repo.delivery(1); delivery.Status != entity.DeliveryFailed {
+		t.Errorf("status = %q, want %q", delivery.Status, entity.DeliveryFailed)
+	}
+}
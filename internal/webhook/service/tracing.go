@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"swagger_petstore/entity"
+	"swagger_petstore/tracing"
+)
+
+// tracedService starts a span around the methods of the service that can fail.
+type tracedService struct {
+	Servicer
+}
+
+// Traced wraps the service so that its calls are traced.
+func Traced(service Servicer) Servicer {
+	return tracedService{Servicer: service}
+}
+
+func (s tracedService) CreateSubscription(ctx context.Context, subscription entity.WebhookSubscription) (_ entity.WebhookSubscription, err error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.CreateSubscription")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.CreateSubscription(ctx, subscription)
+}
+
+func (s tracedService) GetSubscriptions(ctx context.Context) (_ []entity.WebhookSubscription, err error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.GetSubscriptions")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.GetSubscriptions(ctx)
+}
+
+func (s tracedService) GetSubscription(ctx context.Context, subscriptionId int64) (_ entity.WebhookSubscription, err error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.GetSubscription")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.GetSubscription(ctx, subscriptionId)
+}
+
+func (s tracedService) UpdateSubscription(ctx context.Context, subscription entity.WebhookSubscription) (err error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.UpdateSubscription")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.UpdateSubscription(ctx, subscription)
+}
+
+func (s tracedService) DeleteSubscription(ctx context.Context, subscriptionId int64) (err error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.DeleteSubscription")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.DeleteSubscription(ctx, subscriptionId)
+}
+
+func (s tracedService) GetDeliveries(ctx context.Context, subscriptionId int64, limit, offset int) (_ []entity.WebhookDelivery, err error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.GetDeliveries")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.GetDeliveries(ctx, subscriptionId, limit, offset)
+}
+
+func (s tracedService) Redeliver(ctx context.Context, subscriptionId, deliveryId int64) (err error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.Redeliver")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.Redeliver(ctx, subscriptionId, deliveryId)
+}
+
+func (s tracedService) Publish(ctx context.Context, event entity.Event) (err error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.Publish")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.Publish(ctx, event)
+}
+
+func (s tracedService) Dispatch(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.Dispatch")
+	defer func() { tracing.End(span, err) }()
+	return s.Servicer.Dispatch(ctx)
+}
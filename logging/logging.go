@@ -1,12 +1,81 @@
 package logging
 
-import "go.uber.org/zap"
+import (
+	"context"
+	"fmt"
+	"time"
 
-func GetLogger() *zap.Logger {
-	logger, err := zap.NewProduction()
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+
+	// slowQuery is the duration after which a database query is logged as a warning.
+	slowQuery = 500 * time.Millisecond
+)
+
+type loggerKey struct{}
+
+// GetLogger builds the logger with the level (debug, info, warn, error; info by default)
+// and format (json or console; json by default) and makes it the global one.
+func GetLogger(level, format string) *zap.Logger {
+	logger, err := New(level, format)
 	if err != nil {
 		panic(err)
 	}
-	defer logger.Sync()
+	zap.ReplaceGlobals(logger)
 	return logger
 }
+
+func New(level, format string) (*zap.Logger, error) {
+	config := zap.NewProductionConfig()
+	switch format {
+	case "", FormatJSON:
+	case FormatConsole:
+		config = zap.NewDevelopmentConfig()
+	default:
+		return nil, fmt.Errorf("unknown log format %q: must be json or console", format)
+	}
+
+	if level != "" {
+		parsed, err := zapcore.ParseLevel(level)
+		if err != nil {
+			return nil, fmt.Errorf("invalid log level: %w", err)
+		}
+		config.Level = zap.NewAtomicLevelAt(parsed)
+	}
+	return config.Build()
+}
+
+// WithLogger returns a copy of ctx carrying the logger.
+func WithLogger(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger of ctx, or the global logger when ctx has none.
+// Request loggers carry the request and trace ids.
+func FromContext(ctx context.Context) *zap.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*zap.Logger); ok {
+		return logger
+	}
+	return zap.L()
+}
+
+// QueryHook logs database queries with the logger of their context, at debug level or as
+// warnings when they are slow. It is a postgres.QueryHook.
+func QueryHook(ctx context.Context, query string) (context.Context, func(err error)) {
+	start := time.Now()
+	return ctx, func(err error) {
+		elapsed := time.Since(start)
+		logger := FromContext(ctx)
+		switch {
+		case elapsed >= slowQuery:
+			logger.Warn("db: slow query", zap.String("query", query), zap.Duration("latency", elapsed), zap.Error(err))
+		case logger.Core().Enabled(zap.DebugLevel):
+			logger.Debug("db: query", zap.String("query", query), zap.Duration("latency", elapsed), zap.Error(err))
+		}
+	}
+}
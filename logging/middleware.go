@@ -0,0 +1,129 @@
+package logging
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+
+	maxRequestID = 128
+)
+
+type requestIDKey struct{}
+
+// RequestID takes the request id from the X-Request-ID header, or generates one when it is
+// missing or malformed, and echoes it in the response.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// GetRequestID returns the request id of ctx, or an empty string outside of a request.
+func GetRequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// AccessLog puts a logger with the request and trace ids into the request context and logs
+// every request once it is served. It must run after RequestID and the tracing middleware.
+func AccessLog(logger *zap.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			fields := []zap.Field{zap.String("request_id", GetRequestID(r.Context()))}
+			if span := trace.SpanContextFromContext(r.Context()); span.IsValid() {
+				fields = append(fields,
+					zap.String("trace_id", span.TraceID().String()),
+					zap.String("span_id", span.SpanID().String()))
+			}
+			requestLogger := logger.With(fields...)
+
+			ww := &responseWriter{WrapResponseWriter: chiMiddleware.NewWrapResponseWriter(w, r.ProtoMajor), logger: requestLogger}
+			defer func() {
+				status := ww.Status()
+				if status == 0 {
+					status = http.StatusOK
+				}
+				route := ""
+				if rctx := chi.RouteContext(r.Context()); rctx != nil {
+					route = strings.ReplaceAll(rctx.RoutePattern(), "/*/", "/")
+				}
+
+				log := requestLogger.Info
+				if status >= http.StatusInternalServerError {
+					log = requestLogger.Error
+				}
+				log("http request",
+					zap.String("method", r.Method),
+					zap.String("route", route),
+					zap.String("path", r.URL.Path),
+					zap.Int("status", status),
+					zap.Int("bytes", ww.BytesWritten()),
+					zap.Duration("latency", time.Since(start)),
+					zap.String("remote_addr", r.RemoteAddr),
+					zap.String("user_agent", r.UserAgent()))
+			}()
+
+			next.ServeHTTP(ww, r.WithContext(WithLogger(r.Context(), requestLogger)))
+		})
+	}
+}
+
+// FromWriter returns the request logger of a response writer wrapped by AccessLog, or
+// fallback. It serves code that has the writer but not the request.
+func FromWriter(w http.ResponseWriter, fallback *zap.Logger) *zap.Logger {
+	for {
+		switch writer := w.(type) {
+		case *responseWriter:
+			return writer.logger
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = writer.Unwrap()
+		default:
+			return fallback
+		}
+	}
+}
+
+// responseWriter records the response and carries the request logger.
+type responseWriter struct {
+	chiMiddleware.WrapResponseWriter
+	logger *zap.Logger
+}
+
+// Flush is forwarded so streaming responses keep working.
+func (w *responseWriter) Flush() {
+	_ = w.FlushError()
+}
+
+func (w *responseWriter) FlushError() error {
+	return http.NewResponseController(w.WrapResponseWriter).Flush()
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestID {
+		return false
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,58 @@
+// Package mergepatch implements JSON Merge Patch (RFC 7396).
+package mergepatch
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const ContentType = "application/merge-patch+json"
+
+// Apply applies patch to the JSON representation of doc and decodes the result into out.
+func Apply(doc interface{}, patch []byte, out interface{}) error {
+	original, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	var target interface{}
+	if err := json.Unmarshal(original, &target); err != nil {
+		return err
+	}
+
+	var changes interface{}
+	if err := json.Unmarshal(patch, &changes); err != nil {
+		return fmt.Errorf("invalid merge patch: %w", err)
+	}
+
+	merged, err := json.Marshal(merge(target, changes))
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(merged, out); err != nil {
+		return fmt.Errorf("invalid merge patch: %w", err)
+	}
+	return nil
+}
+
+func merge(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = merge(targetObject[key], value)
+	}
+	return targetObject
+}
@@ -0,0 +1,90 @@
+package mergepatch
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// The examples of RFC 7396 appendix A.
+func TestApply(t *testing.T) {
+	tests := []struct {
+		doc, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.doc+" "+tt.patch, func(t *testing.T) {
+			var doc, got, want interface{}
+			if err := json.Unmarshal([]byte(tt.doc), &doc); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			if err := Apply(doc, []byte(tt.patch), &got); err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestApplyStruct(t *testing.T) {
+	type pet struct {
+		Name   string   `json:"name"`
+		Status string   `json:"status,omitempty"`
+		Tags   []string `json:"tags"`
+	}
+	doc := pet{Name: "doggie", Status: "available", Tags: []string{"a", "b"}}
+
+	var got pet
+	if err := Apply(doc, []byte(`{"status":null,"tags":["c"]}`), &got); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	want := pet{Name: "doggie", Tags: []string{"c"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if doc.Status != "available" || len(doc.Tags) != 2 {
+		t.Errorf("Apply changed the original: %+v", doc)
+	}
+}
+
+func TestApplyInvalid(t *testing.T) {
+	type pet struct {
+		Name string `json:"name"`
+	}
+	tests := []struct {
+		name  string
+		patch string
+	}{
+		{"malformed", `{"name":`},
+		{"wrong type", `{"name":1}`},
+		{"not an object", `"doggie"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got pet
+			if err := Apply(pet{Name: "doggie"}, []byte(tt.patch), &got); err == nil {
+				t.Errorf("Apply(%s) = %+v, want an error", tt.patch, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+// Package metrics exposes Prometheus metrics of the HTTP API, the database and the store.
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace = "petstore"
+
+	// BackgroundOperation labels database queries made outside of a request.
+	BackgroundOperation = "background"
+	// UnmatchedOperation labels requests that matched no route.
+	UnmatchedOperation = "unmatched"
+
+	inventoryTimeout = 5 * time.Second
+)
+
+// statements are the query kinds used as label values, anything else is "other".
+var statements = map[string]bool{
+	"select":  true,
+	"insert":  true,
+	"update":  true,
+	"delete":  true,
+	"with":    true,
+	"declare": true,
+	"fetch":   true,
+}
+
+type Metrics struct {
+	registry   *prometheus.Registry
+	operations map[string]string
+
+	requests      *prometheus.CounterVec
+	duration      *prometheus.HistogramVec
+	queries       *prometheus.HistogramVec
+	queryFailures *prometheus.CounterVec
+}
+
+// New registers the metrics of the process and of the db connection pool. operations maps
+// "METHOD /route/{pattern}" to the OpenAPI operationId used as the operation label.
+func New(db *sql.DB, operations map[string]string) *Metrics {
+	m := &Metrics{
+		registry:   prometheus.NewRegistry(),
+		operations: operations,
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "HTTP requests by operation and response status.",
+		}, []string{"operation", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request duration by operation and response status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation", "status"}),
+		queries: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "db",
+			Name:      "query_duration_seconds",
+			Help:      "Database query duration by the operation that made it and statement.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation", "statement"}),
+		queryFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "db",
+			Name:      "query_errors_total",
+			Help:      "Failed database queries by the operation that made them and statement.",
+		}, []string{"operation", "statement"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		collectors.NewDBStatsCollector(db, namespace),
+		m.requests,
+		m.duration,
+		m.queries,
+		m.queryFailures,
+	)
+	return m
+}
+
+// Operations maps the routes of the OpenAPI spec to their operationId.
+func Operations(spec *openapi3.T) map[string]string {
+	operations := map[string]string{}
+	for path, item := range spec.Paths.Map() {
+		for method, operation := range item.Operations() {
+			if operation.OperationID != "" {
+				operations[method+" "+path] = operation.OperationID
+			}
+		}
+	}
+	return operations
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Middleware counts and times requests. It must be used on the base router to see every route.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := chiMiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		defer func() {
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			labels := prometheus.Labels{
+				"operation": m.Operation(r.Context()),
+				"status":    strconv.Itoa(status),
+			}
+			m.requests.With(labels).Inc()
+			m.duration.With(labels).Observe(time.Since(start).Seconds())
+		}()
+		next.ServeHTTP(ww, r)
+	})
+}
+
+// Operation names the route of the request in ctx: its operationId, or the method and route
+// pattern for routes outside the OpenAPI spec.
+func (m *Metrics) Operation(ctx context.Context) string {
+	rctx := chi.RouteContext(ctx)
+	if rctx == nil {
+		return BackgroundOperation
+	}
+	return OperationName(m.operations, rctx.RouteMethod, rctx.RoutePattern())
+}
+
+// OperationName names a chi route pattern with its operationId from operations, or as
+// "METHOD /route" when it has none.
+func OperationName(operations map[string]string, method, pattern string) string {
+	pattern = strings.ReplaceAll(pattern, "/*/", "/")
+	if pattern == "" {
+		return UnmatchedOperation
+	}
+	if len(pattern) > 1 {
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+	route := method + " " + pattern
+	if operation, ok := operations[route]; ok {
+		return operation
+	}
+	return route
+}
+
+// RequestOperation names the route the request is routed to, or is going to be routed to
+// when called before routing, like OperationName.
+func RequestOperation(operations map[string]string, r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.Routes == nil {
+		return UnmatchedOperation
+	}
+	pattern := rctx.Routes.Find(chi.NewRouteContext(), r.Method, r.URL.Path)
+	return OperationName(operations, r.Method, pattern)
+}
+
+// ObserveQuery times a database query, it is a postgres.QueryHook.
+func (m *Metrics) ObserveQuery(ctx context.Context, query string) (context.Context, func(err error)) {
+	start := time.Now()
+	labels := prometheus.Labels{
+		"operation": m.Operation(ctx),
+		"statement": statement(query),
+	}
+	return ctx, func(err error) {
+		m.queries.With(labels).Observe(time.Since(start).Seconds())
+		if err != nil {
+			m.queryFailures.With(labels).Inc()
+		}
+	}
+}
+
+// RegisterInventory exports the number of pets by status, read on every scrape.
+func (m *Metrics) RegisterInventory(inventory func(ctx context.Context) (map[string]int32, error)) {
+	m.registry.MustRegister(&inventoryCollector{
+		inventory: inventory,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "store", "pets"),
+			"Pets in the store by status.",
+			[]string{"status"}, nil,
+		),
+	})
+}
+
+type inventoryCollector struct {
+	inventory func(ctx context.Context) (map[string]int32, error)
+	desc      *prometheus.Desc
+}
+
+func (c *inventoryCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *inventoryCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), inventoryTimeout)
+	defer cancel()
+
+	inventory, err := c.inventory(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+	for status, pets := range inventory {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(pets), status)
+	}
+}
+
+func statement(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "other"
+	}
+	kind := strings.ToLower(fields[0])
+	if !statements[kind] {
+		return "other"
+	}
+	return kind
+}
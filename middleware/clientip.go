@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+type clientIPKey struct{}
+
+// ParseProxies reads trusted proxies given as CIDRs or single IPs.
+func ParseProxies(proxies []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(proxies))
+	for _, proxy := range proxies {
+		if addr, err := netip.ParseAddr(proxy); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: must be an IP or a CIDR", proxy)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// RealIP finds the client IP of the request for ClientIP. Behind the trusted proxies it is
+// read from X-Forwarded-For, walking it from the right while the hops are trusted, so a
+// client can't choose its IP by sending the header itself.
+func RealIP(proxies []netip.Prefix) func(next http.Handler) http.Handler {
+	trusted := func(addr netip.Addr) bool {
+		for _, prefix := range proxies {
+			if prefix.Contains(addr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			host, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				host = r.RemoteAddr
+			}
+			if addr, err := netip.ParseAddr(host); err == nil {
+				addr = addr.Unmap()
+				if trusted(addr) {
+					hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+					for i := len(hops) - 1; i >= 0; i-- {
+						hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+						if err != nil {
+							break
+						}
+						addr = hop.Unmap()
+						if !trusted(addr) {
+							break
+						}
+					}
+				}
+				host = addr.String()
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientIPKey{}, host)))
+		})
+	}
+}
+
+// ClientIP returns the client IP found by RealIP, or an empty string outside of a request.
+func ClientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
@@ -9,7 +9,13 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
-var TokenAuth = jwtauth.New("HS256", []byte("secret"), nil)
+// TokenAuth signs and verifies the access tokens, it is set up with SetSecret.
+var TokenAuth *jwtauth.JWTAuth
+
+// SetSecret sets the key signing the access tokens, it must be called before serving.
+func SetSecret(secret string) {
+	TokenAuth = jwtauth.New("HS256", []byte(secret), nil)
+}
 
 type Token struct {
 	db      *sqlx.DB
@@ -33,10 +39,34 @@ func (a *Token) TokenMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		next.ServeHTTP(w, r)
+		ctx := jwtauth.NewContext(r.Context(), token, nil)
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// UserID returns the user_id claim of the token verified by TokenMiddleware,
+// or an empty string for anonymous requests.
+func UserID(ctx context.Context) string {
+	_, claims, err := jwtauth.FromContext(ctx)
+	if err != nil {
+		return ""
+	}
+	userID, _ := claims["user_id"].(string)
+	return userID
+}
+
+// TokenUserID returns the user_id claim of a valid token in the request, before and
+// outside of the routes guarded by TokenMiddleware. It returns an empty string otherwise.
+func TokenUserID(r *http.Request) string {
+	token, err := jwtauth.VerifyToken(TokenAuth, jwtauth.TokenFromHeader(r))
+	if err != nil || token == nil {
+		return ""
+	}
+	userID, _ := token.Get("user_id")
+	id, _ := userID.(string)
+	return id
+}
+
 func (a *Token) addToBlacklist(ctx context.Context, tokenID, token string, expiresAt time.Time) error {
 	_, err := a.db.ExecContext(ctx, `
 		INSERT INTO token_blacklist (token_id, token, expires_at)
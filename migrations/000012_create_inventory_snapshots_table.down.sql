@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_inventory_snapshots_taken_at;
+DROP TABLE IF EXISTS inventory_snapshots;
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS inventory_snapshots (
+    id SERIAL PRIMARY KEY,
+    taken_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    status TEXT NOT NULL,
+    category TEXT NOT NULL,
+    count INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_inventory_snapshots_taken_at ON inventory_snapshots (taken_at);
@@ -0,0 +1,5 @@
+ALTER TABLE pets DROP CONSTRAINT IF EXISTS pets_category_id_fkey;
+ALTER TABLE pets ADD CONSTRAINT pets_category_id_fkey
+    FOREIGN KEY (category_id) REFERENCES categories(id);
+
+ALTER TABLE categories DROP CONSTRAINT IF EXISTS categories_name_key;
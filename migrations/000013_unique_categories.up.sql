@@ -0,0 +1,17 @@
+UPDATE pets p
+SET category_id = d.keep_id
+FROM (
+    SELECT id, MIN(id) OVER (PARTITION BY name) AS keep_id
+    FROM categories
+) d
+WHERE p.category_id = d.id AND d.id <> d.keep_id;
+
+DELETE FROM categories c
+USING categories k
+WHERE c.name = k.name AND c.id > k.id;
+
+ALTER TABLE categories ADD CONSTRAINT categories_name_key UNIQUE (name);
+
+ALTER TABLE pets DROP CONSTRAINT IF EXISTS pets_category_id_fkey;
+ALTER TABLE pets ADD CONSTRAINT pets_category_id_fkey
+    FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE SET NULL;
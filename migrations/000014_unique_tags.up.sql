@@ -0,0 +1,15 @@
+INSERT INTO pet_tags (pet_id, tag_id)
+SELECT pt.pet_id, d.keep_id
+FROM pet_tags pt
+JOIN (
+    SELECT id, MIN(id) OVER (PARTITION BY name) AS keep_id
+    FROM tags
+) d ON pt.tag_id = d.id
+WHERE d.id <> d.keep_id
+ON CONFLICT DO NOTHING;
+
+DELETE FROM tags t
+USING tags k
+WHERE t.name = k.name AND t.id > k.id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_name ON tags (name);
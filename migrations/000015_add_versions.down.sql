@@ -0,0 +1,3 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS version;
+ALTER TABLE users DROP COLUMN IF EXISTS version;
+ALTER TABLE pets DROP COLUMN IF EXISTS version;
@@ -0,0 +1,13 @@
+DROP TRIGGER IF EXISTS categories_search ON categories;
+DROP TRIGGER IF EXISTS tags_search ON tags;
+DROP TRIGGER IF EXISTS pet_tags_search ON pet_tags;
+DROP TRIGGER IF EXISTS pets_search ON pets;
+DROP FUNCTION IF EXISTS categories_search_trigger();
+DROP FUNCTION IF EXISTS tags_search_trigger();
+DROP FUNCTION IF EXISTS pet_tags_search_trigger();
+DROP FUNCTION IF EXISTS pets_search_trigger();
+DROP FUNCTION IF EXISTS refresh_pet_search(INTEGER[]);
+DROP INDEX IF EXISTS idx_pets_search_text;
+DROP INDEX IF EXISTS idx_pets_search_document;
+ALTER TABLE pets DROP COLUMN IF EXISTS search_text;
+ALTER TABLE pets DROP COLUMN IF EXISTS search_document;
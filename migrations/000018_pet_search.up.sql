@@ -0,0 +1,75 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE pets ADD COLUMN IF NOT EXISTS search_document TSVECTOR;
+ALTER TABLE pets ADD COLUMN IF NOT EXISTS search_text TEXT NOT NULL DEFAULT '';
+
+-- search_document and search_text cover the pet name, its category and its tags,
+-- so they are rebuilt whenever any of them changes.
+CREATE OR REPLACE FUNCTION refresh_pet_search(ids INTEGER[]) RETURNS void AS $$
+    UPDATE pets p
+    SET search_document = d.document, search_text = d.text
+    FROM (
+        SELECT s.id,
+            setweight(to_tsvector('simple', s.name), 'A') ||
+            setweight(to_tsvector('simple', COALESCE(c.name, '')), 'B') ||
+            setweight(to_tsvector('simple', COALESCE(string_agg(t.name, ' '), '')), 'C') AS document,
+            concat_ws(' ', s.name, c.name, string_agg(t.name, ' ')) AS text
+        FROM pets s
+        LEFT JOIN categories c ON c.id = s.category_id
+        LEFT JOIN pet_tags pt ON pt.pet_id = s.id
+        LEFT JOIN tags t ON t.id = pt.tag_id
+        WHERE s.id = ANY(ids)
+        GROUP BY s.id, c.name
+    ) d
+    WHERE p.id = d.id;
+$$ LANGUAGE sql;
+
+CREATE OR REPLACE FUNCTION pets_search_trigger() RETURNS trigger AS $$
+BEGIN
+    PERFORM refresh_pet_search(ARRAY[NEW.id]);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION pet_tags_search_trigger() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        PERFORM refresh_pet_search(ARRAY[OLD.pet_id]);
+    ELSE
+        PERFORM refresh_pet_search(ARRAY[NEW.pet_id]);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION tags_search_trigger() RETURNS trigger AS $$
+BEGIN
+    PERFORM refresh_pet_search(ARRAY(SELECT pet_id FROM pet_tags WHERE tag_id = NEW.id));
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION categories_search_trigger() RETURNS trigger AS $$
+BEGIN
+    PERFORM refresh_pet_search(ARRAY(SELECT id FROM pets WHERE category_id = NEW.id));
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER pets_search
+    AFTER INSERT OR UPDATE OF name, category_id ON pets
+    FOR EACH ROW EXECUTE FUNCTION pets_search_trigger();
+CREATE TRIGGER pet_tags_search
+    AFTER INSERT OR DELETE ON pet_tags
+    FOR EACH ROW EXECUTE FUNCTION pet_tags_search_trigger();
+CREATE TRIGGER tags_search
+    AFTER UPDATE OF name ON tags
+    FOR EACH ROW EXECUTE FUNCTION tags_search_trigger();
+CREATE TRIGGER categories_search
+    AFTER UPDATE OF name ON categories
+    FOR EACH ROW EXECUTE FUNCTION categories_search_trigger();
+
+SELECT refresh_pet_search(ARRAY(SELECT id FROM pets));
+
+CREATE INDEX IF NOT EXISTS idx_pets_search_document ON pets USING GIN (search_document);
+CREATE INDEX IF NOT EXISTS idx_pets_search_text ON pets USING GIN (search_text gin_trgm_ops);
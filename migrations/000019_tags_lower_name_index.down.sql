@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_tags_lower_name;
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_tags_lower_name ON tags (lower(name));
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_orders_username;
+ALTER TABLE orders DROP COLUMN IF EXISTS username;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'customer'
+    CHECK (role IN ('admin', 'staff', 'customer'));
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS username TEXT;
+CREATE INDEX IF NOT EXISTS idx_orders_username ON orders (username);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_orders_user_id;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS username TEXT;
+UPDATE orders o SET username = u.username FROM users u WHERE u.id = o.user_id;
+ALTER TABLE orders DROP COLUMN IF EXISTS user_id;
+CREATE INDEX IF NOT EXISTS idx_orders_username ON orders (username);
@@ -0,0 +1,7 @@
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS user_id INTEGER REFERENCES users (id) ON DELETE SET NULL;
+UPDATE orders o SET user_id = (
+    SELECT u.id FROM users u WHERE u.username = o.username AND u.deleted_at IS NULL ORDER BY u.id LIMIT 1
+) WHERE o.username IS NOT NULL;
+DROP INDEX IF EXISTS idx_orders_username;
+ALTER TABLE orders DROP COLUMN IF EXISTS username;
+CREATE INDEX IF NOT EXISTS idx_orders_user_id ON orders (user_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_outbox_unsequenced;
+DROP INDEX IF EXISTS idx_outbox_stream_seq;
+ALTER TABLE outbox DROP COLUMN IF EXISTS stream_seq;
+DROP SEQUENCE IF EXISTS outbox_stream_seq;
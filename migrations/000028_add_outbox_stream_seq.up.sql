@@ -0,0 +1,10 @@
+CREATE SEQUENCE IF NOT EXISTS outbox_stream_seq;
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS stream_seq BIGINT;
+UPDATE outbox SET stream_seq = sequenced.seq
+FROM (
+    SELECT id, nextval('outbox_stream_seq') AS seq
+    FROM (SELECT id FROM outbox ORDER BY id) ordered
+) sequenced
+WHERE outbox.id = sequenced.id;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_outbox_stream_seq ON outbox (stream_seq);
+CREATE INDEX IF NOT EXISTS idx_outbox_unsequenced ON outbox (id) WHERE stream_seq IS NULL;
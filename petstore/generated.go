@@ -3352,13 +3352,15 @@ func (siw *ServerInterfaceWrapper) GetInventory(w http.ResponseWriter, r *http.R
 func (siw *ServerInterfaceWrapper) PlaceOrder(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
+	ctx = context.WithValue(ctx, Api_keyScopes, []string{})
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		siw.Handler.PlaceOrder(w, r)
 	}))
 
-	// for _, middleware := range siw.HandlerMiddlewares {
-	// 	handler = middleware(handler)
-	// }
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
 
 	handler.ServeHTTP(w, r.WithContext(ctx))
 }
@@ -3378,13 +3380,15 @@ func (siw *ServerInterfaceWrapper) DeleteOrder(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	ctx = context.WithValue(ctx, Api_keyScopes, []string{})
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		siw.Handler.DeleteOrder(w, r, orderId)
 	}))
 
-	// for _, middleware := range siw.HandlerMiddlewares {
-	// 	handler = middleware(handler)
-	// }
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
 
 	handler.ServeHTTP(w, r.WithContext(ctx))
 }
@@ -3404,13 +3408,15 @@ func (siw *ServerInterfaceWrapper) GetOrderById(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	ctx = context.WithValue(ctx, Api_keyScopes, []string{})
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		siw.Handler.GetOrderById(w, r, orderId)
 	}))
 
-	// for _, middleware := range siw.HandlerMiddlewares {
-	// 	handler = middleware(handler)
-	// }
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
 
 	handler.ServeHTTP(w, r.WithContext(ctx))
 }
@@ -3423,9 +3429,9 @@ func (siw *ServerInterfaceWrapper) CreateUser(w http.ResponseWriter, r *http.Req
 		siw.Handler.CreateUser(w, r)
 	}))
 
-	// for _, middleware := range siw.HandlerMiddlewares {
-	// 	handler = middleware(handler)
-	// }
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
 
 	handler.ServeHTTP(w, r.WithContext(ctx))
 }
@@ -3438,9 +3444,9 @@ func (siw *ServerInterfaceWrapper) CreateUsersWithListInput(w http.ResponseWrite
 		siw.Handler.CreateUsersWithListInput(w, r)
 	}))
 
-	// for _, middleware := range siw.HandlerMiddlewares {
-	// 	handler = middleware(handler)
-	// }
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
 
 	handler.ServeHTTP(w, r.WithContext(ctx))
 }
@@ -3474,9 +3480,9 @@ func (siw *ServerInterfaceWrapper) LoginUser(w http.ResponseWriter, r *http.Requ
 		siw.Handler.LoginUser(w, r, params)
 	}))
 
-	// for _, middleware := range siw.HandlerMiddlewares {
-	// 	handler = middleware(handler)
-	// }
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
 
 	handler.ServeHTTP(w, r.WithContext(ctx))
 }
@@ -3489,9 +3495,9 @@ func (siw *ServerInterfaceWrapper) LogoutUser(w http.ResponseWriter, r *http.Req
 		siw.Handler.LogoutUser(w, r)
 	}))
 
-	// for _, middleware := range siw.HandlerMiddlewares {
-	// 	handler = middleware(handler)
-	// }
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
 
 	handler.ServeHTTP(w, r.WithContext(ctx))
 }
@@ -3511,13 +3517,15 @@ func (siw *ServerInterfaceWrapper) DeleteUser(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	ctx = context.WithValue(ctx, Api_keyScopes, []string{})
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		siw.Handler.DeleteUser(w, r, username)
 	}))
 
-	// for _, middleware := range siw.HandlerMiddlewares {
-	// 	handler = middleware(handler)
-	// }
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
 
 	handler.ServeHTTP(w, r.WithContext(ctx))
 }
@@ -3541,9 +3549,9 @@ func (siw *ServerInterfaceWrapper) GetUserByName(w http.ResponseWriter, r *http.
 		siw.Handler.GetUserByName(w, r, username)
 	}))
 
-	// for _, middleware := range siw.HandlerMiddlewares {
-	// 	handler = middleware(handler)
-	// }
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
 
 	handler.ServeHTTP(w, r.WithContext(ctx))
 }
@@ -3563,13 +3571,15 @@ func (siw *ServerInterfaceWrapper) UpdateUser(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	ctx = context.WithValue(ctx, Api_keyScopes, []string{})
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		siw.Handler.UpdateUser(w, r, username)
 	}))
 
-	// for _, middleware := range siw.HandlerMiddlewares {
-	// 	handler = middleware(handler)
-	// }
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
 
 	handler.ServeHTTP(w, r.WithContext(ctx))
 }
@@ -3751,62 +3761,61 @@ func HandlerWithOptions(si ServerInterface, options ChiServerOptions) http.Handl
 // Base64 encoded, gzipped, json marshaled Swagger object
 var swaggerSpec = []string{
 
-	"H4sIAAAAAAAC/+xb+3PbNvL/V1D2O9NvZ6iH7bS500zn6sRJx3dp4qmTa+9iTwqRKxENCbAAKFnn8f9+",
-	"s3iQFElJlC037Vx/sSnitdj97BPgbRCJLBccuFbB5DZQUQIZNY+nOfsBVC64AvwJNzTLU/MYiRiCyTgM",
-	"MlCKziGYlE9hoFc5vjD/7sIglyIHqRmoauRtMBMyozqYBIzrk+NqFOMa5iBxYDn3rW9UWjI+xzb7otVQ",
-	"tgRi+gtEOgiDmyzFjpxm+Przz2OY0SLVpu9zqmEu5KqxOxYHk6PQDzkTc9XeB/apDToK13b09ZPOHdkZ",
-	"a8Ps5OHe24g84dj1jYxBtgSETxqCiZYFhHZL4zDIQZ/j41//8vTpcRj8WlCumV4Fk6dhoBKWn1EcExyP",
-	"x+PB+GhwfPJ2/GTy1deT8dN/B2GgNNWFCiYBzXMpFhB3ydcvXApnKkQKlGPfJtfGvdjmiK4PdPT3GFxt",
-	"sTb+adgHgBVDaniNqYaBZhm0xVYx6DaIQUWS5ZoJHkysiMilbQ0D4EUWTN4HeUojiIOw4mcYxJCyBUiI",
-	"g+uwBpRaj33BIgw+sN8F6CZOahrQDXuPHPc6FvM5w73nidDinUyV2Uj5o95wXUfMgrKUTlPDNjrHUXbF",
-	"amrz7y7sfn3dRlqN9P+TMEPtHlW2bOQM2ahU8nvjr622JRdaCKix5TZgGjLVNlNNAfkxdVlSKemq6rmU",
-	"NM8htup8twVpOWhi2wjjRCdAlBYSaqCrSyIHHluKlEgN4tqW1girtpdtvH5La9Z5xx7uwkDCrwVDqE/e",
-	"W1400LMd1zlYK46Ltg14C1edBnwP6e+rdpraXu9UyzpDRlkaTIJfRMK/NT+GkciCMJgxqfRrO/7vIuFB",
-	"pX8prVpoBkbTqFJLIeNgEhwdnzz5yrKPQ+13oUA6s4O6jT89eQkYylqMccTV4d6is4WSGuH1gW4Pre73",
-	"08OKBWtrOG60VbFkT72750yX5nLo2bfO1qYGIldrpn5DhLDB5VQCqtPhZbW/7S+MiI3BgKiQTK8uUVWt",
-	"pGnOPnwEa/uR8gRobFZxg317pdA5+wesnEs2duUDLXRiFCkVS6tXWZ6yiBlHg41Csv9QZA0auEmQaJ2r",
-	"yWjkJzgZqiWdz0EOmRgJHDDyo9AcqEjkllgJNJ7gqGBinslKFJKYF2GwlEyDb81EzGYr04Q20PSjUSQK",
-	"ri0rPMtwoWP7Cm40Mj49E1GHSF8yHhNRaJIJCYRO8fHSko06Vm5sMhpVuzE45zNhoyKuaaTruk9zpoFm",
-	"364PWF/3bcIUYYpQogwUyAVocolsI5cgFyDJlCqIibCW/k0O/PTinJwMx0TlELEZiwzrh4T8SxQkopzM",
-	"2lu54m4vhGry3guoouv6/9vvvhySc7uoTpiMCdMgzVJEzMxr64eEhJAs4YsFELVkOkogJlqYDjEoNkd6",
-	"pNLERDc0Sj674p5QLpYkgTQn6MkyE/yYcbjBZQI6AUmY/kKR6Ypk9CPjcxIllM9BVSvMGGeGKKYVpDMi",
-	"pG/DNGR4xd8mVJMlXYVkyXRCMKxDeg0BzUUZJ3PgIGkaEspjAje5UECUyMBvmsOSzIDqQoKB3pvTy5Ph",
-	"Fb/iH85niMMvJJBUCEPsDIlJwOOIHA/Hozenl/ifLEAqx8sLpychduYkSln0kbxPQEIlFoiZFrKuSH8r",
-	"ZPpNU9XqHRbHpTBXNEu/HJLT1GiAZgtIVyFSa7iQChqTBaOG1p9fxEyTq2I8PgHyCls8ecSR/jPJgBdE",
-	"GAR/9gH3fokMKhTMipSkjH9Ukys+IO/fJnU4S8iFwl2sqm3NmU6KKXocT+uA5qx89tv6spxOiUJGVlg1",
-	"2XtGl4vtvcJomorpKKNKgxwpGY0yyvhIgl1PjUQOnObMcjIIg5RF4HJmZ0lPcxolgAxq2ovlcjmkpnUo",
-	"5HzkhqrRq/PnL15fvhgcD8fDRGepCatAZurNDFWfRdBlc0amywgtNtPGdXh4lYIa1M1EEAYOa+jthuPh",
-	"0REu5DYUTIKT4Xh4YkINnRjDiGjC/7lQum0oT+OYUKMGqP9O13wAikGGsRGYzGFXTEdsEAhKPxPxyptK",
-	"4NZ95OhKzJDRLwoX8OWJXXHohQsN6zPcDJbL5QB98KCQKXA0AfEDp7S+dp8JWjb+uQSqoca1Rthexcgm",
-	"bMYXtiRjxHE8Hj8u0w6ww8siikAp1P8SAoiyJ5b29c7nfEFTFhPG80KbXsfH7V7/xD7W38BNBPZ1Pcox",
-	"6WUjSHlfDxPCWkBxfYeZapFlFLPJ7SB2uatJPkxOWnSowbs8NjJF6pjSaO5xqumKnMctRbCd/9SFNt+A",
-	"6z81otSI8zOiCiTElt2ejJ+0+6KP40KTmSh4/Gm0pxv7Lc25C40rGWE8+mxV5VJz6NCn74tUM4x/XWVj",
-	"QdMClIlPpkAwTGMxxDaGi0SWUaIgp5JqiIlNlVRL7TCmR6dYLo5OTtIMNEhbnWrIbG1pjYEjBxvOTjGe",
-	"5IrFICE28caMYTRl0r88NRVvV4bFmX4tQK6qLEv55StI+Rr1etnsngUcpVcmEkBtD1BWD1KXXmUgA/t2",
-	"GWjgVhmU6WRjpZ26du/VO5RQ3UcJ6wA8mMogFJWJ0NBFlHjYpjBvXVVuu7rgDHtpyZC8U2bYUYh/j83f",
-	"ExtCg1Hm4RZFMkTtUCPsgzpjNYRMV/2URNupO5Cw4WjIy/1P/B8U/5rOHxX8Ts7d0L81B0F3ljp/yLRO",
-	"p31PqPM560g9M4020mqAtITgjKbKY3BzPaySyEZzq5gp3t2FXT6aGb/hNhF2r44pV7W2PQNrBj91SnoU",
-	"UJu0NZVhq+gxEjus6K08VCmtZmzdadx+AF1IbmpijM9T6JT0d6AvQD9bGZZttUfnZ0TMfKAvzdy/I4H8",
-	"EYJZ9RsFs03QldXr99eoZA8wP2VydtaV4LlCx4ak7Uemk5foWfrjzIeOysWOhZkr/oSwa9mo19QWNfvS",
-	"u9lxu2O9HgbT+udwQ9x9EGI6Qu09w+U+NYuDJlLOOq7lwDaEQwJJTDXd5S9HRZ4KGp9n7jLNJkRjp5fM",
-	"phW9Tabl/+8Ju6dxbAq/NCXfg6aOQz3QQcuBtXH7IaVP9UZEGvRAaQk0Wze+5e6njFO56jjuu3vMakf9",
-	"uldvO38gsFuIKmLOXdw1shambTGe8QVw7S5+7AgRMpojVl3WFonYng65O0EMVFfkcF4u8EBmV4C6WDth",
-	"73EO3DrV7ZE73FNidT+6JhPPRmt+LE8YdOeotjZXF5Pwt9K6TwouUhr5qrfp2qzyrYvFdLcX3R6nSvqm",
-	"vCR1wDrphkn3Cbr8FI+q+eUiB4nt7lu6L4HnsMEdMGZCtlKEDXgb3Zp/O5LEl0ISS6rnKNFyRZz6kfMz",
-	"ZT2syXbscWdEjsbj8ZCc8pVOGJ8TOhULMC+JkIQL7kbj0DR1J8XaHkaClEKqDemoB3UPl4vqYVnSCoPs",
-	"VnvGkI5HnzSb7JMA2FuTjRSgkT2SvJBRQpXnTDOM90DZkE3eBwrfkK9Q5u4Y/Gg8JG/MbQRXGl6Xf4lx",
-	"NezyNWaL/fPUDdKfgbla8buS/mPYp0PY0d8sfd2OXpt39sMuGrlCbfOm5qJQhBaTpytjDwQHU09LgKRi",
-	"Pgc0ywQnacPQnkC7C2aP4VvtPcPDutbuOfdBxDvVDQjLjtgwi7gArOl8ywOaR+XQIXazOQYsoehuIBTu",
-	"hqEDn/lZYW8UmV4/Mp28YmrLFRA7myIpUxpNFg52RnTOFsBteEB8UXwTFJVf6dxEE/cHZq+Sub8J26yZ",
-	"P2bQ9Xgy33S8XEPtPUHSW6ybUJSKOeO1xG1d/K+w1Rmi7adIicUrQZ9mAkQ7ca88v7xj+5CyFFLgLxhX",
-	"BKCRjVKgkmi40f3IKa8pP6AstS8u29eIt4Kw9fnCtmwUR/c1RKE7YzGb+Gnw4iZnEtTgdKatt2sc7qCZ",
-	"Ypy8e/ucLBPgRIuPgGmEGdUR/XQENDs+32nUloKfBj/ggFcsYx16E9E0xfxYksRcMk5TsYTY+11nUHsQ",
-	"teUTpAZFd7siEw/uUQnOKlBZU+hXYq6sDjHu7zitlIZsu/YKe99pk/qKQpeBRLe/3NvyGEJFoUlUSAlc",
-	"N+IZokApi6VNZN96pmzNCh8UQ9lkpK/pMlbrYTlczYhtDuN3AnyvlM0vuTv0NZ9A7MjbusONsBtZ34GB",
-	"1bPVa/eV0P047PIke8sBVzxau9pAPiXr/xihxb2ypQMB5zvQVuOnq8r5d0Go807mg9TbnsX0Ue914H1i",
-	"zf5fT+U67pM6j1crNd8d0Fe5BTekUqbyLhceOMXuD6JozkaLEyPN8qPZdaJeLEBWJclC28+dLuxxyx5f",
-	"NW3/jqn+xWX7wM2wBaFefmRg6hl7UuDoR/L9GcBuirwIW8UXLyDlpnUiWfss7vruvwEAAP//8R5eqv1A",
-	"AAA=",
+	"H4sIAAAAAAAC/+xbe3PbNrb/Kih6Z3o7Qz1sp829mulsnTjpeDdtPHW8293Yk0LkkYiGBFgAlMz16Lvv",
+	"HAB8SKRkyrKbdrb/2BTxOjjnd54A72go00wKEEbTyR3VYQwps4+nGf8RdCaFBvwJtyzNEvsYygjoZBzQ",
+	"FLRmc6CT6imgpsjwhf23CmimZAbKcND1yDs6kyplhk4oF+bkuB7FhYE5KBxYzX1XNmqjuJhjm3vRaqha",
+	"qJz+AqGhAb1NE+woWIqvP/88ghnLE2P7vmQG5lIVG7vjEZ0cBeWQMznX7X3waG3QUbC2o6+fde7IzdgY",
+	"5iYP9t5GWBKOXd+qCFRLQPhkgE6MyiFwWxoHNANzjo///3/Pnx8H9NecCcNNQSfPA6pjnp0xHEOPx+Px",
+	"YHw0OD55N342+erryfj5v2hAtWEm13RCWZYpuYCoS77lwpVwplImwARdOTKaXBv3YpsnujnQ099jcL3F",
+	"xvjnQR8A1gxp4DViBgaGp9AWW82gOxqBDhXPDJeCTpyIyKVrDSiIPKWT9zRLWAgRDWp+BjSChC9AQURv",
+	"gprgZo99wSJxcdvvAswmThoa0A37Ejn+dSTnc457z2Jp5JVKtN1I9aPZcNNEzILxhE0TyzY2x1FuxXpq",
+	"+28VdL++aSOtQfr/KJihdo9qWzbyhmxUKfmD8ddW24oLLQQ02HJHuYFUt83UpoDKMU1ZMqVYUfdcKpZl",
+	"EDl1Xu1AWgaGuDbCBTExEG2kggbompLIQESOIi0Ti7i2pWXz9b3s4vU7Nqd997AKqIJfc45Qn7x3vNhA",
+	"z25cZ+CsOC7aNuAtXHUa8D2kv6/aGeZ6XemWdYaU8YRO6C8yFt/aH8NQpjSgM660+cGN/6uMBa31L2F1",
+	"C0vBahrTeilVRCf06Pjk2VeOfQIav3MNypudyZH7WZIXg6WsxRhPXBPuLTpbKGkQ3hzo99Dq/jA9rFmw",
+	"tobnRlsVK/Y0u5ec6dJcAT37Ntm6qYFXes3Ub4kQtricWkBNOkpZ7W/7cyti7KchzBU3xSWqqpM0y/iH",
+	"j+BsP1IeA4vsKn5w2V4rdMb/BoV3ydaufGC5ia0iJXLp9CrNEh5y62iwUSr+b4asQQM3obExmZ6MRuUE",
+	"J0O9ZPM5qCGXI4kDRuUooAHVocwcsQpYNMFRdGKfSSFzReyLgC4VN1C2pjLis8I2oQ20/VgYylwYx4qS",
+	"ZbjQsXsFtwYZn5zJsEOkr7mIiMwNSaUCwqb4eOnIRh2rNjYZjerdWJyLmXRRkTAsNE3dZxk3wNJv1wes",
+	"r/su5ppwTRjRFgrkAgy5RLaRS1ALUGTKNEREOkv/NgNxenFOToZjojMI+YyHlvVDQv4pcxIyQWbtrVwL",
+	"vxfCDHlfCqim6+Z/2+++HJJzt6iJuYoIN6DsUkTO7Gvnh6SCgCzhiwUQveQmjCEiRtoOEWg+R3qUNsRG",
+	"NyyMP7sWJaFCLkkMSUbQk6U2+LHjcIPLGEwMinDzhSbTgqTsIxdzEsZMzEHXK8y44JYobjQkMyJV2YZp",
+	"yPBavIuZIUtWBGTJTUwMTy29loDNRbkgcxCgWBIQJiICt5nUQLRMody0gCWZATO5Agu9t6eXJ8NrcS0+",
+	"nM8Qh18oIImUltgZEhNDiSNyPByP3p5e4n+yAKU9Ly+8ngTYWZAw4eFH8j4GBbVYIOJGqqYi/SVXyTeb",
+	"qtbssDiuhFmwNPlySE4TqwGGLyApAqTWciGRLCILziytP7+KuCHX+Xh8AuQNtpTkEU/6zyQFkRNpEfzZ",
+	"B9z7JTIo1zDLE5Jw8VFPrsWAvH8XN+GsIJMad1HU25pzE+dT9DglrQOW8eq53NaX1XRa5ip0wmrIvmR0",
+	"tdjeK4ymiZyOUqYNqJFW4ShlXIwUuPX0SGYgWMYdJ2lAEx6Cz5m9JT3NWBgDMmjTXiyXyyGzrUOp5iM/",
+	"VI/enL989cPlq8HxcDyMTZrYsApUqt/OUPV5CF02Z2S7jGhADTfWdZTwqgQ1aJoJGlCPNfR2w/Hw6AgX",
+	"8huiE3oyHA9PbKhhYmsYEU34P5PatA3laRQRZtUA9d/rWhmAyszbiPPIdcV0xAWBoM0LGRWlqQTh3EeG",
+	"rsQOGf2ipajLE/fFoRc+NGzOcDtYLpcD9MGDXCUg0AREB07pfO0+E7Rs/EsFzECDaxthex0j27AZX7iS",
+	"jBXH8Xj8tEx7hB1e5mEIWqP+VxBAlD1ztK93PhcLlvCIcJHlxvY6Pm73+jv2cf4GbkNwr5tRjk0vN4KU",
+	"980wIWgEFDcrzFTzNGWquA/EPnfFuanNSfMONbjKIitTpI5rg+Yep5oW5DxqKYLr/KcutPkGwvypEZVG",
+	"nJ8RnSMhruz2bPys3Rd9nJCGzGQuok+jPd3Yb2nOKrCuZITx6IuizqXm0KFP3+eJ4Rj/+srGgiU5aBuf",
+	"TIFgmMYjiFwMF8o0ZURDxhQzEBGXKumW2mFMj06xWhydnGIpGFCuOrUhs7WlDQaOAlw4O8V4UmgegYLI",
+	"xhsznhibG8BtltiKty/D4ky/5qCKOsvS5fI1pMoa9XrZ7IEFHG0KGwmgttPVzYHq0qsMZGHfLgMN/CqD",
+	"smFzpXt17cGrdyihfogSNgH4aCqDUNQ2QkMXUeFhl8K8Y/Me6oIz7KUlQ3Kl7bCjAP8e278nLoQGq8zD",
+	"HYpkibpHjbAP6ozTEDIt+imJcVN3IGHL0VAp9z/x/6j4N2z+pOD3cu6G/p09CFo56spDpnU63XvCvM9Z",
+	"R+qZbXSR1gZIKwjOWKIhuK8eVktkq7nV3BbvVsEmieijufUbfhNB9+qYctVr2623gp8mJT0KqJu0bSrD",
+	"TtFnYB5Z9E4eupLWZmzdadx+BJMrgYM0F/MEOiX9HZgLMC+K86gt6o3dnWGVxQf6ys79OxLIHyGY1b9R",
+	"MLsJuqp6/f4GlewA81MlZ2ddCZ4vdGxJ2v7BTfwaPUt/nJWho/axY27nij4h7Fo2Ck9Z9qF3u+O2/3oZ",
+	"TOefgy1x96MQ0xFq7xku96lZPGoi5a3jWg7sQjgkkETMsPv85SjPsIp7nvrLNNsQjZ1e8wT2gLL0/P89",
+	"Yfc0imzhlyXkezDMc6gHOlg1sDFuP6T0qd7I0IAZaKOApevGt9r9lAumio7jvtVTVjua17162/lHAruD",
+	"qCb23MVfI2th2k484mIBwviLH/eECCnLEKs+a8Mql7Ub/k4QB90VOZxXCxzI7BpQF2sn7D3OgVunuj1y",
+	"hwdKrOlH12RSstGZH8cTDt05qhXNmphkeSut+6TgImFhWfW2XTerfOtisd3dRbenqZK+rS5JPWKddMuk",
+	"abL/FE+q+dUijxLbPUbpfisoPW6EBw1WJjbThy1YHN3Zf/ckkK+lIm4bJbeJUQXxqknOz7TzvjYTckeh",
+	"ITkaj8dDcioKE2O9k03x3Bhf4omzkMKPxqFJ4k+RjTuoBKWk0ltS1RLwPdwxqo5jSStEclvtGV96Hn3S",
+	"TLNPcmBZ0zc96Mg6SZarMGa65Npm+F+CaEsW+hCYfEO+QjzYHwiPIXlrbzH4kvI6Nird0MMuH2W33z+/",
+	"3YKMGdgrGb8rZDyFXXsM+/ubpb0PR7bLZfvhGo1jrnd5aHv5CIvHUiSFtSNSAM6HpiaR8zmgqSc4SRui",
+	"7lTbX1p7Cn99pR/fXV/pQ9FypbvB4tgRWWYRH9RtOvTq0OdJOfQYu9keV1ZQdBu2+22Az/6ssTcKbS8s",
+	"obzhese1EjebJgnXBs0ZDvYGds4XIFzIQcpC+zYo6nKlc+x+ADB7leHL27WbdfinDOSeTubbjqwbqH0g",
+	"SHqLdRuKEjnnopEMrov/DbZ6Q7T7ZCp2eCXo72xg6SbuVTuo7u0eUupCCspLyzUBaGTDBJgiBm5NP3LK",
+	"WQ4pde2Ly/bV5J0gbH0SsSvDxdF9DVHgz23sJn4avLrNuAI9OJ0Z5+3Wp7CXFbggV+9e4rVSQYz8CJia",
+	"2FEdkVFHsHPPJ0Eb9Sr60+BHHPCGp7xDb0KWJJpkoEhsLy4niVxCVPpdb1B7ELXjs6YNilb3RS0luEcV",
+	"OOsgZk2h38i5djrERXlvqtAG0t3aK3OzS31lbqpAottf7m15LKF4CzrMlQJhNuIZokFrh6VtZN+VTNmZ",
+	"TR4UQ7lEpa/pslbrsNyvYcS2h/j3AnyvVK9c8v6w+Eoflu91hyJBN+q+Awu5F8UPjh0P5L7Pr9ytClzx",
+	"aO0qBfmUYvljhB0PyrIOA1UFnO/AOGswLerAoAtCnXdAD1J9d/bTR/XXgfeJtf6/Pc3ruL/qvWGjtL06",
+	"3I/1snuemC0pmJ1GLUpQ5fd/nIVfRixOrKSrD3jXCX61AFWXQHPjPr26cEc/e3xhtfubqubXn+3DP8sy",
+	"VIPqgwdbB9mTAk8/kq9NX4pK8bYKOqXwtJ/Wi2TtE72b1X8GAOusa/eJQQAA",
 }
 
 // GetSwagger returns the content of the embedded swagger specification file
@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const cursorBatch = 500
+
+// EachRow runs query through a server-side cursor and calls scan for every row. Rows are
+// fetched cursorBatch at a time, so memory use does not depend on the size of the result.
+func EachRow(ctx context.Context, db *sqlx.DB, query string, args []interface{}, scan func(rows *sqlx.Rows) error) error {
+	tx, err := db.BeginTxx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DECLARE each_row NO SCROLL CURSOR FOR "+query, args...); err != nil {
+		return fmt.Errorf("failed to declare cursor: %w", err)
+	}
+
+	fetch := fmt.Sprintf("FETCH %d FROM each_row", cursorBatch)
+	for {
+		rows, err := tx.QueryxContext(ctx, fetch)
+		if err != nil {
+			return fmt.Errorf("failed to fetch rows: %w", err)
+		}
+
+		fetched := 0
+		for rows.Next() {
+			fetched++
+			if err := scan(rows); err != nil {
+				rows.Close()
+				return err
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if fetched < cursorBatch {
+			return nil
+		}
+	}
+}
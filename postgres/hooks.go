@@ -0,0 +1,132 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"sync"
+)
+
+// QueryHook is called before every statement sent through a connection opened by
+// NewPostgresDB. It returns the context of the statement and a function called when the
+// statement is done, with its error. Queries are done once their first rows are read.
+type QueryHook func(ctx context.Context, query string) (context.Context, func(err error))
+
+var (
+	hooksMu sync.RWMutex
+	hooks   []QueryHook
+)
+
+// AddQueryHook adds a hook to all connections, it should be called before the database is used.
+func AddQueryHook(hook QueryHook) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	hooks = append(hooks, hook)
+}
+
+// run calls the hooks around one statement.
+func run[T any](ctx context.Context, query string, statement func(ctx context.Context) (T, error)) (T, error) {
+	hooksMu.RLock()
+	current := hooks
+	hooksMu.RUnlock()
+
+	done := make([]func(error), 0, len(current))
+	for _, hook := range current {
+		var finish func(error)
+		ctx, finish = hook(ctx, query)
+		done = append(done, finish)
+	}
+
+	result, err := statement(ctx)
+	for i := len(done) - 1; i >= 0; i-- {
+		// Skipped statements are run again by database/sql in another way.
+		if err != driver.ErrSkip {
+			done[i](err)
+		}
+	}
+	return result, err
+}
+
+// hookedConnector opens connections that run the hooks.
+type hookedConnector struct {
+	driver.Connector
+}
+
+func (c hookedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &hookedConn{conn: conn}, nil
+}
+
+// hookedConn forwards everything to the lib/pq connection.
+type hookedConn struct {
+	conn driver.Conn
+}
+
+func (c *hookedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.conn.Prepare(query)
+}
+
+func (c *hookedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.conn.Prepare(query)
+}
+
+func (c *hookedConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *hookedConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *hookedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.conn.Begin()
+}
+
+func (c *hookedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return run(ctx, query, func(ctx context.Context) (driver.Rows, error) {
+		return queryer.QueryContext(ctx, query, args)
+	})
+}
+
+func (c *hookedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return run(ctx, query, func(ctx context.Context) (driver.Result, error) {
+		return execer.ExecContext(ctx, query, args)
+	})
+}
+
+func (c *hookedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *hookedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *hookedConn) IsValid() bool {
+	if validator, ok := c.conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
@@ -1,22 +1,73 @@
 package postgres
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
 	"swagger_petstore/config"
 
 	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	migratePostgres "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
-func NewMigration(c *config.DBConfig) *migrate.Migrate {
+const migrationsSource = "file://migrations"
+
+func NewMigration(c *config.DBConfig) (*migrate.Migrate, error) {
 	m, err := migrate.New(
-		"file://migrations",
+		migrationsSource,
 		"postgres://"+c.User+":"+c.Password+"@"+c.Host+":"+c.Port+"/"+c.DBName+"?sslmode="+c.SSLMode+"")
 
 	if err != nil {
-		log.Fatalf("failed to migrate db: %s", err.Error())
+		return nil, fmt.Errorf("failed to migrate db: %w", err)
+	}
+
+	return m, nil
+}
+
+// LatestMigration returns the version of the newest migration in the migrations directory.
+func LatestMigration() (uint, error) {
+	source, err := (&file.File{}).Open(migrationsSource)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open migrations: %w", err)
 	}
+	defer source.Close()
 
-	return m
+	version, err := source.First()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read migrations: %w", err)
+	}
+	for {
+		next, err := source.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read migrations: %w", err)
+		}
+		version = next
+	}
+}
+
+// CheckMigrations returns a check that the schema recorded by golang-migrate is at version
+// and not left dirty by a failed migration.
+func CheckMigrations(db *sqlx.DB, version uint) func(ctx context.Context) error {
+	query := `SELECT version, dirty FROM ` + pq.QuoteIdentifier(migratePostgres.DefaultMigrationsTable) + ` LIMIT 1`
+	return func(ctx context.Context) error {
+		var current int64
+		var dirty bool
+		if err := db.QueryRowContext(ctx, query).Scan(&current, &dirty); err != nil {
+			return fmt.Errorf("failed to read schema version: %w", err)
+		}
+		if dirty {
+			return fmt.Errorf("schema version %d is dirty", current)
+		}
+		if current != int64(version) {
+			return fmt.Errorf("schema version is %d, expected %d", current, version)
+		}
+		return nil
+	}
 }
@@ -1,22 +1,31 @@
 package postgres
 
 import (
+	"database/sql"
+	"fmt"
 	"swagger_petstore/config"
 
 	"github.com/golang-migrate/migrate"
 	"github.com/jmoiron/sqlx"
-	"go.uber.org/zap"
+	"github.com/lib/pq"
 )
 
-func NewPostgresDB(conf *config.DBConfig, logger *zap.Logger) *sqlx.DB {
-	db, err := sqlx.Open("postgres", conf.GetDBURL())
+// NewPostgresDB opens the database and migrates it to the latest schema.
+func NewPostgresDB(conf *config.DBConfig) (*sqlx.DB, error) {
+	connector, err := pq.NewConnector(conf.GetDBURL())
 	if err != nil {
-		logger.Fatal("Failed to connect to database: ", zap.Error(err))
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
+	db := sqlx.NewDb(sql.OpenDB(hookedConnector{Connector: connector}), "postgres")
 
-	m := NewMigration(conf)
+	m, err := NewMigration(conf)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
 	if err := m.Up(); err != nil && err.Error() != migrate.ErrNoChange.Error() {
-		logger.Fatal("error migrate: ", zap.Error(err))
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate db: %w", err)
 	}
-	return db
+	return db, nil
 }
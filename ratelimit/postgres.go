@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresStore shares the buckets between the instances of the service.
+type PostgresStore struct {
+	db *sqlx.DB
+}
+
+func NewPostgresStore(db *sqlx.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit) (bool, float64, error) {
+	allowed, tokens, err := s.take(ctx, key, limit)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return allowed, tokens, err
+	}
+
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO rate_limit_buckets (key, tokens, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (key) DO NOTHING`,
+		key, float64(limit.Requests-1),
+	)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to create rate limit bucket: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 1 {
+		return true, float64(limit.Requests - 1), nil
+	}
+	// Another instance created the bucket first.
+	return s.take(ctx, key, limit)
+}
+
+// take refills and takes from an existing bucket in one statement, the row lock keeps
+// concurrent requests of the client from taking the same token.
+func (s *PostgresStore) take(ctx context.Context, key string, limit Limit) (bool, float64, error) {
+	var allowed bool
+	var tokens float64
+	err := s.db.QueryRowContext(ctx, `
+		WITH current AS (
+			SELECT tokens, updated_at FROM rate_limit_buckets WHERE key = $1 FOR UPDATE
+		), refilled AS (
+			SELECT LEAST($2::float8, tokens + EXTRACT(EPOCH FROM NOW() - updated_at)::float8 * $3::float8) AS tokens
+			FROM current
+		)
+		UPDATE rate_limit_buckets b
+		SET tokens = CASE WHEN r.tokens >= 1 THEN r.tokens - 1 ELSE r.tokens END, updated_at = NOW()
+		FROM refilled r
+		WHERE b.key = $1
+		RETURNING r.tokens >= 1, b.tokens`,
+		key, float64(limit.Requests), limit.Rate(),
+	).Scan(&allowed, &tokens)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, 0, err
+	}
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to take from rate limit bucket: %w", err)
+	}
+	return allowed, tokens, nil
+}
+
+// Purge deletes the buckets idle for longer than MaxPeriod, they are full again.
+func (s *PostgresStore) Purge(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM rate_limit_buckets WHERE updated_at < NOW() - $1::interval`,
+		fmt.Sprintf("%d seconds", int64(MaxPeriod.Seconds())))
+	if err != nil {
+		return fmt.Errorf("failed to purge rate limit buckets: %w", err)
+	}
+	return nil
+}
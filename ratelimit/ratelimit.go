@@ -0,0 +1,148 @@
+// Package ratelimit limits the requests of every client with token buckets, per operation
+// or across the operations without a limit of their own.
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"swagger_petstore/metrics"
+	"swagger_petstore/middleware"
+	"swagger_petstore/responder"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// Off disables the limit of an operation.
+	Off = "off"
+
+	// MaxPeriod bounds the period of a limit, buckets idle that long are full again.
+	MaxPeriod = 24 * time.Hour
+
+	// defaultBucket is shared by the operations limited by the default limit.
+	defaultBucket = "*"
+)
+
+// Limit allows Requests per Period, in bursts of up to Requests. The zero Limit is off.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// ParseLimit reads a limit written as "<requests>/<period>", such as 10/1m or 10/m, or off.
+func ParseLimit(spec string) (Limit, error) {
+	if spec == Off {
+		return Limit{}, nil
+	}
+	requests, period, ok := strings.Cut(spec, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid limit %q: must be <requests>/<period> or off", spec)
+	}
+	n, err := strconv.Atoi(requests)
+	if err != nil || n <= 0 {
+		return Limit{}, fmt.Errorf("invalid limit %q: requests must be a positive number", spec)
+	}
+	if period != "" && !strings.ContainsAny(period[:1], "0123456789") {
+		period = "1" + period
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 || d > MaxPeriod {
+		return Limit{}, fmt.Errorf("invalid limit %q: period must be a duration up to %s", spec, MaxPeriod)
+	}
+	return Limit{Requests: n, Period: d}, nil
+}
+
+func (l Limit) Off() bool {
+	return l.Requests == 0
+}
+
+// Rate returns the tokens added per second.
+func (l Limit) Rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// Wait returns how long a bucket holding tokens takes to hold target.
+func (l Limit) Wait(tokens, target float64) time.Duration {
+	if tokens >= target {
+		return 0
+	}
+	return time.Duration((target - tokens) / l.Rate() * float64(time.Second))
+}
+
+type Limiter struct {
+	store      Store
+	limit      Limit
+	routes     map[string]Limit
+	operations map[string]string
+	responder  responder.Responder
+	logger     *zap.Logger
+}
+
+// New limits every client to limit across the operations without one in routes. routes is
+// keyed by operationId, or by "METHOD /route" for routes outside the OpenAPI spec, and
+// operations maps the routes to their operationId. Clients are told apart by user when
+// they send a valid token and otherwise by the IP found by middleware.RealIP.
+func New(store Store, limit Limit, routes map[string]Limit, operations map[string]string,
+	responder responder.Responder, logger *zap.Logger) *Limiter {
+	return &Limiter{
+		store:      store,
+		limit:      limit,
+		routes:     routes,
+		operations: operations,
+		responder:  responder,
+		logger:     logger,
+	}
+}
+
+// Middleware answers 429 with Retry-After once the client is out of tokens. Every limited
+// response carries the RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and
+// RateLimit-Policy headers. When the store fails, requests are let through.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		operation := metrics.RequestOperation(l.operations, r)
+		limit, bucket := l.limit, defaultBucket
+		if routeLimit, ok := l.routes[operation]; ok {
+			limit, bucket = routeLimit, operation
+		}
+		if limit.Off() || operation == metrics.UnmatchedOperation {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := bucket + "|" + l.client(r)
+		allowed, tokens, err := l.store.Take(r.Context(), key, limit)
+		if err != nil {
+			l.logger.Warn("ratelimit: store failed, request let through", zap.String("key", key), zap.Error(err))
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header := w.Header()
+		header.Set("RateLimit-Limit", strconv.Itoa(limit.Requests))
+		header.Set("RateLimit-Remaining", strconv.Itoa(int(tokens)))
+		header.Set("RateLimit-Reset", seconds(limit.Wait(tokens, float64(limit.Requests))))
+		header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%s", limit.Requests, seconds(limit.Period)))
+		if !allowed {
+			header.Set("Retry-After", seconds(limit.Wait(tokens, 1)))
+			l.responder.ErrorTooManyRequests(w, fmt.Errorf("rate limit of %d requests per %s exceeded", limit.Requests, limit.Period))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// client keys the bucket by the user of a valid token, or by the client IP.
+func (l *Limiter) client(r *http.Request) string {
+	if userID := middleware.TokenUserID(r); userID != "" {
+		return "user:" + userID
+	}
+	return "ip:" + middleware.ClientIP(r.Context())
+}
+
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
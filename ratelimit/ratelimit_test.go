@@ -0,0 +1,134 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    Limit
+		wantErr bool
+	}{
+		{spec: "off", want: Limit{}},
+		{spec: "10/1m", want: Limit{Requests: 10, Period: time.Minute}},
+		{spec: "10/m", want: Limit{Requests: 10, Period: time.Minute}},
+		{spec: "600/30s", want: Limit{Requests: 600, Period: 30 * time.Second}},
+		{spec: "1/h", want: Limit{Requests: 1, Period: time.Hour}},
+		{spec: "5/24h", want: Limit{Requests: 5, Period: MaxPeriod}},
+		{spec: "", wantErr: true},
+		{spec: "Off", wantErr: true},
+		{spec: "10", wantErr: true},
+		{spec: "10/", wantErr: true},
+		{spec: "/1m", wantErr: true},
+		{spec: "0/1m", wantErr: true},
+		{spec: "-1/1m", wantErr: true},
+		{spec: "ten/1m", wantErr: true},
+		{spec: "10/0s", wantErr: true},
+		{spec: "10/-1m", wantErr: true},
+		{spec: "10/25h", wantErr: true},
+		{spec: "10/fortnight", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseLimit(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseLimit(%q) = %+v, want an error", tt.spec, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseLimit(%q): %v", tt.spec, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLimit(%q) = %+v, want %+v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBucket(t *testing.T) {
+	limit := Limit{Requests: 10, Period: 10 * time.Second}
+	tests := []struct {
+		name        string
+		tokens      float64
+		elapsed     time.Duration
+		wantAllowed bool
+		wantTokens  float64
+		wantWait    time.Duration
+	}{
+		{"full", 10, 0, true, 9, 0},
+		{"last token", 1, 0, true, 0, time.Second},
+		{"empty", 0, 0, false, 0, time.Second},
+		{"partial token", 0.5, 0, false, 0.5, 500 * time.Millisecond},
+		{"refilled", 0, 3 * time.Second, true, 2, 0},
+		{"refilled to a token", 0.5, 500 * time.Millisecond, true, 0, time.Second},
+		{"refill capped", 5, time.Hour, true, 9, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowed, tokens := take(refill(limit, tt.tokens, tt.elapsed))
+			if allowed != tt.wantAllowed || tokens != tt.wantTokens {
+				t.Errorf("take = %v, %v, want %v, %v", allowed, tokens, tt.wantAllowed, tt.wantTokens)
+			}
+			if wait := limit.Wait(tokens, 1); wait != tt.wantWait {
+				t.Errorf("Wait(%v, 1) = %v, want %v", tokens, wait, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	limit := Limit{Requests: 3, Period: time.Minute}
+	store := NewMemoryStore()
+
+	for i := range limit.Requests {
+		allowed, tokens, err := store.Take(ctx, "a", limit)
+		if err != nil || !allowed {
+			t.Fatalf("request %d: allowed %v, error %v, want allowed", i+1, allowed, err)
+		}
+		// The clock keeps refilling a fraction of a token between the requests.
+		if want := limit.Requests - i - 1; int(tokens) != want {
+			t.Errorf("request %d: %v tokens left, want %v", i+1, tokens, want)
+		}
+	}
+	if allowed, _, _ := store.Take(ctx, "a", limit); allowed {
+		t.Errorf("request over the burst allowed")
+	}
+	if allowed, _, _ := store.Take(ctx, "b", limit); !allowed {
+		t.Errorf("another key shares the bucket")
+	}
+
+	// A third of the period gives back one token.
+	store.buckets["a"].updated = store.buckets["a"].updated.Add(-limit.Period / 3)
+	if allowed, _, _ := store.Take(ctx, "a", limit); !allowed {
+		t.Errorf("request after the refill denied")
+	}
+	if allowed, _, _ := store.Take(ctx, "a", limit); allowed {
+		t.Errorf("refill gave more than one token")
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	ctx := context.Background()
+	limit := Limit{Requests: 3, Period: time.Minute}
+	store := NewMemoryStore()
+
+	store.Take(ctx, "idle", limit)
+	store.Take(ctx, "busy", limit)
+	store.buckets["idle"].full = time.Now().Add(-time.Second)
+	store.buckets["busy"].full = time.Now().Add(time.Hour)
+	store.swept = time.Now().Add(-memorySweepInterval)
+
+	store.Take(ctx, "other", limit)
+	if _, ok := store.buckets["idle"]; ok {
+		t.Errorf("full bucket kept")
+	}
+	if _, ok := store.buckets["busy"]; !ok {
+		t.Errorf("bucket still refilling dropped")
+	}
+}
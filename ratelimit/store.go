@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memorySweepInterval is how often the memory store drops the buckets that are full again.
+const memorySweepInterval = time.Minute
+
+// Store keeps the token buckets.
+type Store interface {
+	// Take takes a token from the bucket of key, creating a full bucket if there is none,
+	// and reports whether one was left and how many tokens remain.
+	Take(ctx context.Context, key string, limit Limit) (bool, float64, error)
+}
+
+// refill returns the tokens of a bucket holding tokens after elapsed.
+func refill(limit Limit, tokens float64, elapsed time.Duration) float64 {
+	return min(float64(limit.Requests), tokens+elapsed.Seconds()*limit.Rate())
+}
+
+// take removes a token when there is a whole one.
+func take(tokens float64) (bool, float64) {
+	if tokens >= 1 {
+		return true, tokens - 1
+	}
+	return false, tokens
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	// full is when the bucket is back to its capacity and can be forgotten.
+	full time.Time
+}
+
+// MemoryStore keeps the buckets of a single instance.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*bucket{}, swept: time.Now()}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit) (bool, float64, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.swept) >= memorySweepInterval {
+		for k, b := range s.buckets {
+			if now.After(b.full) {
+				delete(s.buckets, k)
+			}
+		}
+		s.swept = now
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Requests), updated: now}
+		s.buckets[key] = b
+	}
+	allowed, tokens := take(refill(limit, b.tokens, now.Sub(b.updated)))
+	b.tokens = tokens
+	b.updated = now
+	b.full = now.Add(limit.Wait(tokens, float64(limit.Requests)))
+	return allowed, tokens, nil
+}
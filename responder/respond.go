@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"swagger_petstore/logging"
 
 	"github.com/ptflp/godecoder"
 	"go.uber.org/zap"
@@ -17,9 +18,15 @@ type Response struct {
 
 type Responder interface {
 	OutputJSON(w http.ResponseWriter, responseData interface{})
+	OutputAccepted(w http.ResponseWriter, responseData interface{})
 	ErrorUnauthorized(w http.ResponseWriter, err error)
 	ErrorBadRequest(w http.ResponseWriter, err error)
 	ErrorForbidden(w http.ResponseWriter, err error)
+	ErrorNotFound(w http.ResponseWriter, err error)
+	ErrorConflict(w http.ResponseWriter, err error)
+	ErrorUnsupportedMediaType(w http.ResponseWriter, err error)
+	ErrorPreconditionFailed(w http.ResponseWriter, err error)
+	ErrorTooManyRequests(w http.ResponseWriter, err error)
 	ErrorInternal(w http.ResponseWriter, err error)
 }
 
@@ -35,12 +42,20 @@ func NewResponder(decoder godecoder.Decoder, logger *zap.Logger) Responder {
 func (r *Respond) OutputJSON(w http.ResponseWriter, responseData interface{}) {
 	w.Header().Set("Content-Type", "application/json;charset=utf-8")
 	if err := r.Encode(w, responseData); err != nil {
-		r.log.Error("responder json encode error", zap.Error(err))
+		r.logger(w).Error("responder json encode error", zap.Error(err))
+	}
+}
+
+func (r *Respond) OutputAccepted(w http.ResponseWriter, responseData interface{}) {
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusAccepted)
+	if err := r.Encode(w, responseData); err != nil {
+		r.logger(w).Error("responder json encode error", zap.Error(err))
 	}
 }
 
 func (r *Respond) ErrorBadRequest(w http.ResponseWriter, err error) {
-	r.log.Info("http response bad request status code", zap.Error(err))
+	r.logger(w).Info("http response bad request status code", zap.Error(err))
 	w.Header().Set("Content-Type", "application/json;charset=utf-8")
 	w.WriteHeader(http.StatusBadRequest)
 	if err := r.Encode(w, Response{
@@ -48,12 +63,12 @@ func (r *Respond) ErrorBadRequest(w http.ResponseWriter, err error) {
 		Message: err.Error(),
 		Data:    nil,
 	}); err != nil {
-		r.log.Info("response writer error on write", zap.Error(err))
+		r.logger(w).Info("response writer error on write", zap.Error(err))
 	}
 }
 
 func (r *Respond) ErrorForbidden(w http.ResponseWriter, err error) {
-	r.log.Warn("http resposne forbidden", zap.Error(err))
+	r.logger(w).Warn("http resposne forbidden", zap.Error(err))
 	w.Header().Set("Content-Type", "application/json;charset=utf-8")
 	w.WriteHeader(http.StatusForbidden)
 	if err := r.Encode(w, Response{
@@ -61,12 +76,77 @@ func (r *Respond) ErrorForbidden(w http.ResponseWriter, err error) {
 		Message: err.Error(),
 		Data:    nil,
 	}); err != nil {
-		r.log.Error("response writer error on write", zap.Error(err))
+		r.logger(w).Error("response writer error on write", zap.Error(err))
+	}
+}
+
+func (r *Respond) ErrorNotFound(w http.ResponseWriter, err error) {
+	r.logger(w).Info("http response not found", zap.Error(err))
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusNotFound)
+	if err := r.Encode(w, Response{
+		Success: false,
+		Message: err.Error(),
+		Data:    nil,
+	}); err != nil {
+		r.logger(w).Error("response writer error on write", zap.Error(err))
+	}
+}
+
+func (r *Respond) ErrorConflict(w http.ResponseWriter, err error) {
+	r.logger(w).Info("http response conflict", zap.Error(err))
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusConflict)
+	if err := r.Encode(w, Response{
+		Success: false,
+		Message: err.Error(),
+		Data:    nil,
+	}); err != nil {
+		r.logger(w).Error("response writer error on write", zap.Error(err))
+	}
+}
+
+func (r *Respond) ErrorUnsupportedMediaType(w http.ResponseWriter, err error) {
+	r.logger(w).Info("http response unsupported media type", zap.Error(err))
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusUnsupportedMediaType)
+	if err := r.Encode(w, Response{
+		Success: false,
+		Message: err.Error(),
+		Data:    nil,
+	}); err != nil {
+		r.logger(w).Error("response writer error on write", zap.Error(err))
+	}
+}
+
+func (r *Respond) ErrorPreconditionFailed(w http.ResponseWriter, err error) {
+	r.logger(w).Info("http response precondition failed", zap.Error(err))
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusPreconditionFailed)
+	if err := r.Encode(w, Response{
+		Success: false,
+		Message: err.Error(),
+		Data:    nil,
+	}); err != nil {
+		r.logger(w).Error("response writer error on write", zap.Error(err))
+	}
+}
+
+func (r *Respond) ErrorTooManyRequests(w http.ResponseWriter, err error) {
+	r.logger(w).Info("http response too many requests", zap.Error(err))
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusTooManyRequests)
+	if err := r.Encode(w, Response{
+		Success: false,
+		Message: err.Error(),
+		Data:    nil,
+	}); err != nil {
+		r.logger(w).Error("response writer error on write", zap.Error(err))
 	}
 }
 
 func (r *Respond) ErrorUnauthorized(w http.ResponseWriter, err error) {
-	r.log.Warn("http resposne Unauthorized", zap.Error(err))
+	r.logger(w).Warn("http resposne Unauthorized", zap.Error(err))
 	w.Header().Set("Content-Type", "application/json;charset=utf-8")
 	w.WriteHeader(http.StatusUnauthorized)
 	if err := r.Encode(w, Response{
@@ -74,7 +154,7 @@ func (r *Respond) ErrorUnauthorized(w http.ResponseWriter, err error) {
 		Message: err.Error(),
 		Data:    nil,
 	}); err != nil {
-		r.log.Error("response writer error on write", zap.Error(err))
+		r.logger(w).Error("response writer error on write", zap.Error(err))
 	}
 }
 
@@ -82,7 +162,7 @@ func (r *Respond) ErrorInternal(w http.ResponseWriter, err error) {
 	if errors.Is(err, context.Canceled) {
 		return
 	}
-	r.log.Error("http response internal error", zap.Error(err))
+	r.logger(w).Error("http response internal error", zap.Error(err))
 	w.Header().Set("Content-Type", "application/json;charset=utf-8")
 	w.WriteHeader(http.StatusInternalServerError)
 	if err := r.Encode(w, Response{
@@ -90,6 +170,11 @@ func (r *Respond) ErrorInternal(w http.ResponseWriter, err error) {
 		Message: err.Error(),
 		Data:    nil,
 	}); err != nil {
-		r.log.Error("response writer error on write", zap.Error(err))
+		r.logger(w).Error("response writer error on write", zap.Error(err))
 	}
 }
+
+// logger returns the request logger, so response errors are logged with the request id.
+func (r *Respond) logger(w http.ResponseWriter) *zap.Logger {
+	return logging.FromWriter(w, r.log)
+}
@@ -2,6 +2,8 @@ package run
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/go-chi/chi/v5"
 	httpSwagger "github.com/swaggo/http-swagger"
@@ -9,17 +11,42 @@ import (
 
 	"net/http"
 	"os"
+	"os/signal"
+	"swagger_petstore/authz"
+	"swagger_petstore/config"
+	"swagger_petstore/entity"
+	"swagger_petstore/event"
+	"swagger_petstore/health"
+	aRepository "swagger_petstore/internal/audit/repository"
+	aService "swagger_petstore/internal/audit/service"
+	cRepository "swagger_petstore/internal/category/repository"
+	cService "swagger_petstore/internal/category/service"
 	"swagger_petstore/internal/handler"
+	jRepository "swagger_petstore/internal/job/repository"
+	jService "swagger_petstore/internal/job/service"
 	oRepository "swagger_petstore/internal/order/repository"
 	oService "swagger_petstore/internal/order/service"
+	obRepository "swagger_petstore/internal/outbox/repository"
+	obService "swagger_petstore/internal/outbox/service"
 	pRepository "swagger_petstore/internal/pet/repository"
 	pService "swagger_petstore/internal/pet/service"
+	tRepository "swagger_petstore/internal/tag/repository"
+	tService "swagger_petstore/internal/tag/service"
 	uRepository "swagger_petstore/internal/user/repository"
 	uService "swagger_petstore/internal/user/service"
+	wRepository "swagger_petstore/internal/webhook/repository"
+	wService "swagger_petstore/internal/webhook/service"
+	"swagger_petstore/logging"
+	"swagger_petstore/metrics"
 	"swagger_petstore/middleware"
 	"swagger_petstore/petstore"
+	"swagger_petstore/postgres"
+	"swagger_petstore/ratelimit"
 	"swagger_petstore/responder"
 	"swagger_petstore/server"
+	"swagger_petstore/tracing"
+	"syscall"
+	"time"
 
 	jsoniter "github.com/json-iterator/go"
 
@@ -35,6 +62,22 @@ const (
 	GeneralError
 )
 
+const (
+	inventorySnapshotInterval  = time.Hour
+	purgeInterval              = 24 * time.Hour
+	deletedRetention           = 30 * 24 * time.Hour
+	jobWorkers                 = 2
+	staleJobInterval           = time.Minute
+	staleJobTimeout            = 5 * time.Minute
+	outboxRelayInterval        = time.Second
+	outboxFollowInterval       = time.Second
+	webhookDispatchInterval    = time.Second
+	eventsWebhookTimeout       = 5 * time.Second
+	tracingShutdownTimeout     = 5 * time.Second
+	rateLimitPurgeInterval     = time.Hour
+	loginFailuresPurgeInterval = time.Hour
+)
+
 // Application - интерфейс приложения
 type Application interface {
 	Runner
@@ -53,46 +96,289 @@ type Bootstraper interface {
 
 // App - структура приложения
 type App struct {
-	logger *zap.Logger
-	db     *sqlx.DB
-	srv    *server.Server
-	Sig    chan os.Signal
+	conf         *config.Config
+	logger       *zap.Logger
+	db           *sqlx.DB
+	srv          *server.Server
+	orderService oService.Servicer
+	petService   pService.Servicer
+	userService  uService.Servicer
+	jobService   jService.Servicer
+	outbox       obService.Servicer
+	webhooks     wService.Servicer
+	health       *health.Checker
+	// rateLimits - общее хранилище лимитов в БД, nil при хранении в памяти
+	rateLimits *ratelimit.PostgresStore
+	// stopTracing - отправка оставшихся спанов при завершении
+	stopTracing func(ctx context.Context) error
+	Sig         chan os.Signal
 }
 
 // NewApp - конструктор приложения
-func NewApp(db *sqlx.DB, logger *zap.Logger) *App {
-	return &App{db: db, logger: logger, Sig: make(chan os.Signal, 1)}
+func NewApp(db *sqlx.DB, logger *zap.Logger, conf *config.Config) *App {
+	return &App{conf: conf, db: db, logger: logger, Sig: make(chan os.Signal, 1)}
 }
 
-// Run - запуск приложения
+// Run - запуск приложения до сигнала SIGINT/SIGTERM или ошибки сервера.
+// Порядок остановки: readiness, HTTP-сервер с ожиданием текущих запросов,
+// фоновые задачи, трейсы, пул соединений с БД.
 func (a *App) Run() int {
-	ctx, cancel := context.WithCancel(context.Background())
+	signal.Notify(a.Sig, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(a.Sig)
+
+	// Незарегистрированное имя администратора мог бы занять любой пользователь
+	if err := a.grantAdmins(context.Background()); err != nil {
+		a.logger.Error("app: failed to grant admin role", zap.Error(err))
+		if err := a.db.Close(); err != nil {
+			a.logger.Error("app: failed to close database", zap.Error(err))
+		}
+		_ = a.logger.Sync()
+		return GeneralError
+	}
 
-	errGroup, ctx := errgroup.WithContext(ctx)
+	errGroup, groupCtx := errgroup.WithContext(context.Background())
+	serverCtx, stopServer := context.WithCancel(context.Background())
+	defer stopServer()
+	// Фоновые задачи работают, пока сервер дожидается текущих запросов
+	ctx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
 
 	errGroup.Go(func() error {
-		sigInt := <-a.Sig
-		a.logger.Info("signal interrupt recieved", zap.Stringer("os_signal", sigInt))
-		cancel()
+		select {
+		case sigInt := <-a.Sig:
+			a.logger.Info("signal interrupt recieved", zap.Stringer("os_signal", sigInt))
+			// Запросы принимаются, пока балансировщик не заметит неготовность
+			a.health.ShutDown()
+			select {
+			case <-time.After(a.conf.Server.ShutdownDelay):
+			case <-groupCtx.Done():
+			}
+		case <-groupCtx.Done():
+			a.health.ShutDown()
+		}
+		stopServer()
 		return nil
 	})
 
 	errGroup.Go(func() error {
-		err := a.srv.Serve(ctx)
-		if err != nil && err != http.ErrServerClosed {
+		defer stopWorkers()
+		if err := a.srv.Serve(serverCtx); err != nil {
 			a.logger.Error("app: server error", zap.Error(err))
 			return err
 		}
 		return nil
 	})
 
-	if err := errGroup.Wait(); err != nil {
+	errGroup.Go(func() error {
+		a.every(ctx, inventorySnapshotInterval, "inventory snapshot", a.orderService.SnapshotInventory)
+		return nil
+	})
+
+	errGroup.Go(func() error {
+		a.every(ctx, purgeInterval, "purge deleted", a.purgeDeleted)
+		return nil
+	})
+
+	for i := 0; i < jobWorkers; i++ {
+		errGroup.Go(func() error {
+			a.jobService.Work(ctx)
+			return nil
+		})
+	}
+
+	errGroup.Go(func() error {
+		a.every(ctx, outboxRelayInterval, "outbox relay", a.outbox.Relay)
+		return nil
+	})
+
+	errGroup.Go(func() error {
+		a.every(ctx, outboxFollowInterval, "outbox follow", a.outbox.Follow)
+		return nil
+	})
+
+	errGroup.Go(func() error {
+		a.every(ctx, webhookDispatchInterval, "webhook dispatch", a.webhooks.Dispatch)
+		return nil
+	})
+
+	errGroup.Go(func() error {
+		a.every(ctx, staleJobInterval, "requeue stale jobs", a.requeueStaleJobs)
+		return nil
+	})
+
+	errGroup.Go(func() error {
+		a.every(ctx, loginFailuresPurgeInterval, "purge login failures", a.purgeLoginFailures)
+		return nil
+	})
+
+	if a.rateLimits != nil {
+		errGroup.Go(func() error {
+			a.every(ctx, rateLimitPurgeInterval, "purge rate limits", a.rateLimits.Purge)
+			return nil
+		})
+	}
+
+	err := errGroup.Wait()
+	a.logger.Info("app: background jobs stopped")
+
+	ctxTracing, cancelTracing := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+	defer cancelTracing()
+	if err := a.stopTracing(ctxTracing); err != nil {
+		a.logger.Error("app: failed to flush traces", zap.Error(err))
+	}
+
+	if err := a.db.Close(); err != nil {
+		a.logger.Error("app: failed to close database", zap.Error(err))
+	}
+	a.logger.Info("app: stopped")
+	_ = a.logger.Sync()
+
+	if err != nil {
 		return GeneralError
 	}
 
 	return NoError
 }
 
+// every - периодический запуск фоновой задачи до отмены контекста
+func (a *App) every(ctx context.Context, interval time.Duration, name string, job func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	logger := a.logger.With(zap.String("job", name))
+	ctx = logging.WithLogger(ctx, logger)
+	for {
+		if err := job(ctx); err != nil {
+			logger.Error("app: background job error", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeDeleted - окончательное удаление питомцев и пользователей после срока хранения
+func (a *App) purgeDeleted(ctx context.Context) error {
+	pets, err := a.petService.PurgeDeletedPets(ctx, deletedRetention)
+	if err != nil {
+		return err
+	}
+	users, err := a.userService.PurgeDeletedUsers(ctx, deletedRetention)
+	if err != nil {
+		return err
+	}
+	a.logger.Info("app: purged deleted rows", zap.Int64("pets", pets), zap.Int64("users", users))
+	return nil
+}
+
+// purgeLoginFailures - удаление устаревших неудачных попыток входа
+func (a *App) purgeLoginFailures(ctx context.Context) error {
+	purged, err := a.userService.PurgeLoginFailures(ctx)
+	if err != nil {
+		return err
+	}
+	if purged > 0 {
+		a.logger.Info("app: purged login failures", zap.Int64("rows", purged))
+	}
+	return nil
+}
+
+// grantAdmins - назначение роли admin пользователям из конфигурации, все они должны быть зарегистрированы
+func (a *App) grantAdmins(ctx context.Context) error {
+	for _, username := range a.conf.Auth.Admins {
+		err := a.userService.SetUserRole(ctx, username, entity.RoleAdmin)
+		if errors.Is(err, entity.ErrNotFound) {
+			return fmt.Errorf("admin %q is not registered, register it before granting the role", username)
+		}
+		if err != nil {
+			return fmt.Errorf("admin %q: %w", username, err)
+		}
+	}
+	return nil
+}
+
+// eventSinks - получатели доменных событий из outbox
+func (a *App) eventSinks() []event.Sink {
+	sinks := []event.Sink{event.NewLogSink(a.logger), a.webhooks}
+	if url := a.conf.Events.WebhookURL; url != "" {
+		sinks = append(sinks, event.NewWebhookSink(url, eventsWebhookTimeout))
+	}
+	return sinks
+}
+
+// requeueStaleJobs - возврат в очередь задач, чей обработчик остановился
+func (a *App) requeueStaleJobs(ctx context.Context) error {
+	jobs, err := a.jobService.RequeueStale(ctx, staleJobTimeout)
+	if err != nil {
+		return err
+	}
+	if jobs > 0 {
+		a.logger.Warn("app: requeued stale jobs", zap.Int64("jobs", jobs))
+	}
+	return nil
+}
+
+// operations - operationId маршрутов OpenAPI для меток метрик
+func (a *App) operations() map[string]string {
+	spec, err := petstore.GetSwagger()
+	if err != nil {
+		a.logger.Error("app: failed to load OpenAPI spec, metrics are labeled by route", zap.Error(err))
+		return map[string]string{}
+	}
+	return metrics.Operations(spec)
+}
+
+// rateLimiter - ограничение частоты запросов клиентов, лимиты проверены при загрузке конфигурации
+func (a *App) rateLimiter(respond responder.Responder, operations map[string]string) *ratelimit.Limiter {
+	conf := a.conf.RateLimit
+	limit, _ := ratelimit.ParseLimit(conf.Default)
+	routes := make(map[string]ratelimit.Limit, len(conf.Routes))
+	for operation, spec := range conf.Routes {
+		routes[operation], _ = ratelimit.ParseLimit(spec)
+	}
+
+	var store ratelimit.Store = ratelimit.NewMemoryStore()
+	if conf.Store == "postgres" {
+		a.rateLimits = ratelimit.NewPostgresStore(a.db)
+		store = a.rateLimits
+	}
+	return ratelimit.New(store, limit, routes, operations, respond, a.logger)
+}
+
+// requireSecurity - проверка токена для операций OpenAPI, у которых в спецификации указан
+// security, остальные операции открыты
+func requireSecurity(authenticate func(http.Handler) http.Handler) petstore.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		secured := authenticate(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			if ctx.Value(petstore.Api_keyScopes) == nil && ctx.Value(petstore.Petstore_authScopes) == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			secured.ServeHTTP(w, r)
+		})
+	}
+}
+
+// healthChecker - проверки готовности: доступность БД и версия схемы
+func (a *App) healthChecker() *health.Checker {
+	checker := health.NewChecker()
+	checker.Add("database", a.db.PingContext)
+
+	version, err := postgres.LatestMigration()
+	if err != nil {
+		a.logger.Error("app: failed to read migrations", zap.Error(err))
+		checker.Add("migrations", func(ctx context.Context) error { return err })
+		return checker
+	}
+	checker.Add("migrations", postgres.CheckMigrations(a.db, version))
+	return checker
+}
+
 func (a *App) Bootstrap(options ...interface{}) Runner {
 	decoder := godecoder.NewDecoder(jsoniter.Config{
 		EscapeHTML:             true,
@@ -103,29 +389,129 @@ func (a *App) Bootstrap(options ...interface{}) Runner {
 	respond := responder.NewResponder(decoder, a.logger)
 
 	r := chi.NewRouter()
+	middleware.SetSecret(a.conf.Auth.JWTSecret)
 	token := middleware.NewTokenManager(a.db)
 
-	r.Get("/swagger/*", httpSwagger.Handler(
-		httpSwagger.URL("http://localhost:8080/swagger/doc.json")))
-	middlewares := []petstore.MiddlewareFunc{
-		token.TokenMiddleware,
-		token.BlacklistMiddleware,
+	stopTracing, err := tracing.Setup(a.conf.Tracing.Exporter)
+	if err != nil {
+		a.logger.Error("app: tracing disabled", zap.Error(err))
+		stopTracing = func(ctx context.Context) error { return nil }
 	}
+	a.stopTracing = stopTracing
+	postgres.AddQueryHook(tracing.QueryHook)
+	postgres.AddQueryHook(logging.QueryHook)
+	r.Use(tracing.Middleware)
+	r.Use(logging.RequestID)
+	r.Use(logging.AccessLog(a.logger))
+	// прокси проверены при загрузке конфигурации
+	proxies, _ := middleware.ParseProxies(a.conf.Server.TrustedProxies)
+	r.Use(middleware.RealIP(proxies))
 
+	operations := a.operations()
+	m := metrics.New(a.db.DB, operations)
+	postgres.AddQueryHook(m.ObserveQuery)
+	r.Use(m.Middleware)
+	r.Use(a.rateLimiter(respond, operations).Middleware)
+	r.Handle("/metrics", m.Handler())
+
+	a.health = a.healthChecker()
+	r.Get("/healthz", a.health.Live)
+	r.Get("/readyz", a.health.Ready)
+
+	r.Get("/swagger/*", httpSwagger.Handler(
+		httpSwagger.URL(a.conf.Swagger.URL)))
 	uRep := uRepository.NewUserRepository(a.db)
 	pRep := pRepository.NewRepository(a.db)
 	oRep := oRepository.NewOrderRepository(a.db)
+	cRep := cRepository.NewCategoryRepository(a.db)
+	tRep := tRepository.NewTagRepository(a.db)
+	aRep := aRepository.NewAuditRepository(a.db)
+	jRep := jRepository.NewJobRepository(a.db)
+	obRep := obRepository.NewOutboxRepository(a.db)
+	wRep := wRepository.NewWebhookRepository(a.db)
+
+	aServ := aService.NewAuditService(aRep)
+	uServ := uService.NewUserService(uRep, aServ, a.conf.Auth)
+	pServ := pService.PetService(pRep, aServ)
+	oServ := oService.NewService(pRep, oRep, aServ)
+	cServ := cService.NewCategoryService(pRep, cRep)
+	tServ := tService.NewTagService(tRep)
+	jServ := jService.NewJobService(jRep, a.logger)
+	wServ := wService.NewWebhookService(wRep)
+	a.webhooks = wService.Traced(wServ)
+	obServ := obService.NewOutboxService(obRep, event.NewBus(), a.eventSinks()...)
+	jServ.Register(pService.ImportJob, pServ.RunImportJob)
+	jServ.Register(pService.ReindexJob, pServ.RunReindexJob)
+	m.RegisterInventory(oServ.GetInventory)
+	a.orderService = oService.Traced(oServ)
+	a.petService = pService.Traced(pServ)
+	a.userService = uService.Traced(uServ)
+	a.jobService = jService.Traced(jServ)
+	a.outbox = obService.Traced(obServ)
+	// authenticate - проверка токена и роли пользователя, порядок важен
+	authorizer := authz.New(authz.DefaultPolicy(), operations, a.userService.GetUserRole, respond, a.logger)
+	authenticate := chi.Chain(token.TokenMiddleware, token.BlacklistMiddleware, authorizer.Middleware)
+	middlewares := []petstore.MiddlewareFunc{requireSecurity(authenticate.Handler)}
+
+	controller := handler.NewAPI(respond, a.userService, a.petService, a.orderService, cService.Traced(cServ),
+		tService.Traced(tServ), aService.Traced(aServ), a.jobService, a.webhooks, a.outbox)
+
+	auth := r.With(authenticate...)
+	auth.Get("/store/inventory/history", controller.GetInventoryHistory)
+	auth.Get("/pet/search", controller.SearchPets)
+	auth.Post("/pet/import", controller.ImportPets)
+	auth.Patch("/pet/{petId}", controller.PatchPet)
+	auth.Get("/pet/{petId}/history", controller.GetPetHistory)
+	auth.Get("/jobs/{jobId}", controller.GetJob)
+	auth.Get("/events", controller.StreamEvents)
+
+	// admin - служебные маршруты для роли admin, при mTLS доступны только с клиентским сертификатом
+	admin := auth
+	if a.conf.Server.TLS.ClientCAFile != "" {
+		admin = auth.With(server.RequireClientCert)
+	}
+	admin.Post("/pet/search/reindex", controller.ReindexPetSearch)
+	admin.Post("/pet/{petId}/restore", controller.RestorePet)
+	admin.Get("/audit", controller.FindAuditEntries)
+	admin.Post("/user/{username}/restore", controller.RestoreUser)
+	admin.Post("/user/{username}/unlock", controller.UnlockUser)
+	admin.Put("/user/{username}/role", controller.SetUserRole)
+	admin.Route("/webhooks", func(r chi.Router) {
+		r.Post("/", controller.CreateWebhook)
+		r.Get("/", controller.GetWebhooks)
+		r.Get("/{webhookId}", controller.GetWebhook)
+		r.Put("/{webhookId}", controller.UpdateWebhook)
+		r.Delete("/{webhookId}", controller.DeleteWebhook)
+		r.Get("/{webhookId}/deliveries", controller.GetWebhookDeliveries)
+		r.Post("/{webhookId}/deliveries/{deliveryId}/redeliver", controller.RedeliverWebhook)
+	})
+	auth.Route("/export", func(r chi.Router) {
+		r.Get("/pets", controller.ExportPets)
+		r.Get("/orders", controller.ExportOrders)
+		r.Get("/users", controller.ExportUsers)
+	})
+	auth.Route("/category", func(r chi.Router) {
+		r.Post("/", controller.CreateCategory)
+		r.Get("/", controller.GetCategories)
+		r.Get("/{categoryId}", controller.GetCategoryById)
+		r.Put("/{categoryId}", controller.UpdateCategory)
+		r.Delete("/{categoryId}", controller.DeleteCategory)
+		r.Get("/{categoryId}/pets", controller.FindPetsByCategory)
+	})
+	auth.Route("/tag", func(r chi.Router) {
+		r.Get("/", controller.GetTags)
+		r.Delete("/unused", controller.DeleteUnusedTags)
+		r.Put("/{tagId}", controller.RenameTag)
+		r.Delete("/{tagId}", controller.DeleteTag)
+		r.Post("/{tagId}/merge", controller.MergeTags)
+	})
 
-	uServ := uService.NewUserService(uRep)
-	pServ := pService.PetService(pRep)
-	oServ := oService.NewService(pRep, oRep)
-	controller := handler.NewAPI(respond, uServ, pServ, oServ)
 	optionsServer := petstore.ChiServerOptions{
 		BaseRouter:  r,
 		Middlewares: middlewares,
 	}
 	h := petstore.HandlerWithOptions(controller, optionsServer)
-	a.srv = server.NewServer(h)
+	a.srv = server.NewServer(h, a.logger, a.conf.Server)
 
 	return a
 }
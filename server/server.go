@@ -2,52 +2,153 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
-
-	"log"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	_ "swagger_petstore/cmd/docs"
+	"swagger_petstore/config"
+
+	"go.uber.org/zap"
 )
 
+type drainKey struct{}
+
 type Server struct {
 	HttpServer *http.Server
+	logger     *zap.Logger
+	tls        config.TLSConfig
+	// redirect sends plain HTTP to HTTPS, it is nil unless configured.
+	redirect *http.Server
+
+	drainTimeout time.Duration
+	// draining is closed when the shutdown starts.
+	draining chan struct{}
+	inFlight atomic.Int64
 }
 
-func NewServer(r http.Handler) *Server {
-	s := &http.Server{
-		Addr:         ":8080",
-		Handler:      r,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
-	}
+// NewServer serves r until Serve's context is cancelled, then waits up to the shutdown timeout for
+// the requests in flight before closing the remaining connections. With TLS configured it
+// serves HTTPS with HTTP/2.
+func NewServer(r http.Handler, logger *zap.Logger, conf config.ServerConfig) *Server {
 	server := &Server{
-		HttpServer: s,
+		logger:       logger,
+		tls:          conf.TLS,
+		drainTimeout: conf.ShutdownTimeout,
+		draining:     make(chan struct{}),
+	}
+	server.HttpServer = &http.Server{
+		Addr:         conf.Addr,
+		Handler:      server.track(r),
+		ReadTimeout:  conf.ReadTimeout,
+		WriteTimeout: conf.WriteTimeout,
+		ErrorLog:     zap.NewStdLog(logger),
+	}
+	server.HttpServer.RegisterOnShutdown(func() { close(server.draining) })
+	if conf.TLS.Enabled() && conf.TLS.RedirectAddr != "" {
+		server.redirect = &http.Server{
+			Addr:         conf.TLS.RedirectAddr,
+			Handler:      redirectHandler(conf.Addr),
+			ReadTimeout:  conf.ReadTimeout,
+			WriteTimeout: conf.WriteTimeout,
+			ErrorLog:     zap.NewStdLog(logger),
+		}
 	}
 	return server
 }
 
+// Serve listens until ctx is cancelled and then shuts down gracefully. An error of a
+// listener, such as a port in use or an unreadable certificate, is returned right away.
 func (s *Server) Serve(ctx context.Context) error {
-	var err error
+	if s.tls.Enabled() {
+		tlsConfig, err := newTLSConfig(s.tls, s.logger)
+		if err != nil {
+			return fmt.Errorf("server: %w", err)
+		}
+		s.HttpServer.TLSConfig = tlsConfig
+	}
 
-	chErr := make(chan error)
+	chErr := make(chan error, 1)
 	go func() {
-		fmt.Println("Starting server...")
-		if err := s.HttpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server error: %v", err)
+		if s.tls.Enabled() {
+			s.logger.Info("server: starting", zap.String("addr", s.HttpServer.Addr), zap.Bool("tls", true),
+				zap.Bool("client_auth", s.tls.ClientCAFile != ""))
+			chErr <- s.HttpServer.ListenAndServeTLS("", "")
+			return
 		}
+		s.logger.Info("server: starting", zap.String("addr", s.HttpServer.Addr))
+		chErr <- s.HttpServer.ListenAndServe()
 	}()
 
+	chRedirectErr := make(chan error, 1)
+	if s.redirect != nil {
+		go func() {
+			s.logger.Info("server: redirecting to HTTPS", zap.String("addr", s.redirect.Addr))
+			chRedirectErr <- s.redirect.ListenAndServe()
+		}()
+	}
+
 	select {
-	case <-chErr:
-		return err
+	case err := <-chErr:
+		s.closeRedirect()
+		return fmt.Errorf("server: %w", err)
+	case err := <-chRedirectErr:
+		_ = s.HttpServer.Close()
+		return fmt.Errorf("server: redirect: %w", err)
 	case <-ctx.Done():
 	}
+	s.closeRedirect()
 
-	ctxShutdown, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	s.logger.Info("server: draining",
+		zap.Int64("in_flight", s.inFlight.Load()),
+		zap.Duration("timeout", s.drainTimeout))
+	ctxShutdown, cancel := context.WithTimeout(context.Background(), s.drainTimeout)
 	defer cancel()
-	err = s.HttpServer.Shutdown(ctxShutdown)
+	err := s.HttpServer.Shutdown(ctxShutdown)
+	if errors.Is(err, context.DeadlineExceeded) {
+		s.logger.Warn("server: drain timed out, closing connections", zap.Int64("in_flight", s.inFlight.Load()))
+		err = s.HttpServer.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("server: shutdown: %w", err)
+	}
+	if err := <-chErr; !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("server: %w", err)
+	}
+	s.logger.Info("server: stopped")
+	return nil
+}
 
-	return err
+func (s *Server) closeRedirect() {
+	if s.redirect != nil {
+		_ = s.redirect.Close()
+	}
+}
+
+// track counts the requests in flight and lets them see the shutdown through UntilDrain.
+func (s *Server) track(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.inFlight.Add(1)
+		defer s.inFlight.Add(-1)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), drainKey{}, s.draining)))
+	})
+}
+
+// UntilDrain returns a copy of the request context that is also cancelled when the server
+// starts shutting down. Long-lived responses such as event streams use it to end early
+// instead of holding the shutdown until the drain timeout.
+func UntilDrain(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	if draining, ok := ctx.Value(drainKey{}).(chan struct{}); ok {
+		go func() {
+			select {
+			case <-draining:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+	return ctx, cancel
 }